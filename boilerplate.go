@@ -3,9 +3,13 @@ package resource
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
 	"log"
-	"path/filepath"
+	"log/slog"
+	"reflect"
+	"time"
 )
 
 type MetadataField struct {
@@ -28,15 +32,26 @@ type (
 //	        log.Fatal(err)
 //	      }
 //		}
+//
+// The returned function also handles "install [-copy] [DIR]" as the first argument after the executable.
+// It creates in, out, and check symbolic links (or copies) to the executable in DIR (default /opt/resource).
+// It also handles "dev COMMAND [flags]" to run Get, Put, or Check locally with the request built from flags;
+// run "my-resource dev check -h" for details. Finally, "schema" writes the JSON Schema document returned by Schema.
 func Run[ResourceParams, GetParams, PutParams, Version any](
 	in Get[ResourceParams, GetParams, Version],
 	out Put[ResourceParams, PutParams, Version],
 	check Check[ResourceParams, Version],
 ) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
-	return RunWithCustomization(Customization{
-		LoggerPrefix: log.Default().Prefix(),
-		LoggerFlags:  log.Default().Flags(),
-	}, in, out, check)
+	return RunWithCustomization(defaultCustomization(), in, out, check)
+}
+
+// defaultCustomization is the Customization Run and RunGetVersion use.
+func defaultCustomization() Customization {
+	return Customization{
+		LoggerPrefix:  log.Default().Prefix(),
+		LoggerFlags:   log.Default().Flags(),
+		ProtectStdout: true,
+	}
 }
 
 // Customization allows you to configure the behavior of the Run function.
@@ -45,46 +60,237 @@ type Customization struct {
 	LoggerPrefix          string
 	LoggerFlags           int
 	DisallowUnknownFields bool
+
+	// ShutdownGracePeriod is how long Get, Put, or Check may keep running after the context is cancelled
+	// due to SIGINT or SIGTERM. When it elapses the process exits with status 1.
+	// The zero value waits for the function to return until a second signal is received,
+	// which makes the process exit with status 1.
+	ShutdownGracePeriod time.Duration
+
+	// GetTimeout, PutTimeout, and CheckTimeout set a deadline on the context passed to the respective function.
+	// When the deadline is reached and the function returns an error, the error is a *TimeoutError.
+	// The zero value means no deadline.
+	GetTimeout   time.Duration
+	PutTimeout   time.Duration
+	CheckTimeout time.Duration
+
+	// LogLevel and LogFormat configure the *slog.Logger passed to functions run by RunStructured.
+	LogLevel  slog.Level
+	LogFormat LogFormat
+
+	// CommandFromArgument allows the command name to be passed as the second argument
+	// (for example "/bin/resource check") when the base name of the first argument is not a command name.
+	// This lets the same binary work without in, out, and check symlinks.
+	CommandFromArgument bool
+
+	// Debug writes the decoded request, how long the function took, and the response to stderr
+	// (with secrets redacted). Tracing is also enabled when the environment variable RESOURCE_DEBUG is true
+	// or the source has the field "debug": true. The logger passed to RunStructured functions logs debug records
+	// whenever tracing is enabled.
+	Debug bool
+
+	// CaptureFile is a path the function returned by Run appends a Capture of each in, out, and check call to.
+	// The environment variable RESOURCE_CAPTURE_FILE overrides it.
+	CaptureFile string
+
+	// CheckReturnsRequestedVersion makes check write the requested version when Check returns no versions.
+	// Enable it when Check only returns versions newer than the requested one and fails when the
+	// requested version no longer exists. It has no effect on the first check, which has no requested version.
+	CheckReturnsRequestedVersion bool
+
+	// GetFiles makes in write the version and metadata to files in the Get directory after Get succeeds.
+	// Use DefaultGetFiles for the conventional "version" and "metadata.json" files.
+	// The zero value writes no files.
+	GetFiles GetFiles
+
+	// ProtectStdout redirects the process standard output (os.Stdout and file descriptor 1 on unix)
+	// to the stderr writer while Get, Put, or Check runs, so stray writes by libraries or child processes
+	// do not corrupt the response. The output is redacted and captured like other stderr output.
+	// Standard output is shared by the whole process: when invocations overlap, the first one to start
+	// redirects it for all of them and the last one to return restores it.
+	// Run and RunStructured enable it.
+	ProtectStdout bool
 }
 
 // RunWithCustomization calls the given Get, Put, and Check functions based on the command name.
+// The context passed to them is cancelled when the process receives SIGINT or SIGTERM.
+// It panics with a *VersionError when Version can not encode as a JSON object with string values.
 func RunWithCustomization[ResourceParams, GetParams, PutParams, Version any](
 	customization Customization,
 	in Get[ResourceParams, GetParams, Version],
 	out Put[ResourceParams, PutParams, Version],
 	check Check[ResourceParams, Version],
 ) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	return runWithLogger(customization, in.run, out, check)
+}
+
+// runWithLogger returns the function RunWithCustomization and RunGetVersionWithCustomization return.
+// The functions it calls receive a *log.Logger that writes to stderr.
+func runWithLogger[ResourceParams, GetParams, PutParams, Version any](
+	customization Customization,
+	in func(context.Context, *log.Logger, inRequest[ResourceParams, GetParams, Version], []string) (inResponse[Version], error),
+	out Put[ResourceParams, PutParams, Version],
+	check Check[ResourceParams, Version],
+) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	mustCheckVersionType[Version]()
 	return func(stdout io.Writer, stderr io.Writer, stdin io.Reader, args []string) error {
-		ctx := context.Background()
-		stderrLogger := log.New(stderr, customization.LoggerPrefix, customization.LoggerFlags)
-		var err error
-		switch filepath.Base(args[0]) {
-		case "in":
-			err = handleJSON(ctx, customization, stdout, stderrLogger, stdin, args[1:], in.run)
-		case "out":
-			err = handleJSON(ctx, customization, stdout, stderrLogger, stdin, args[1:], out.run)
-		case "check":
-			err = handleJSON(ctx, customization, stdout, stderrLogger, stdin, args[1:], check.run)
-		}
+		secrets := newRedactor(stderr)
+		stderrLogger := log.New(secrets, customization.LoggerPrefix, customization.LoggerFlags)
+		newLogger := func(bool) *log.Logger { return stderrLogger }
+		return dispatch(customization, stdout, newStderrLoggers(stderrLogger), stderrLogger, secrets, stdin, args, newLogger, in, out.run, check.run)
+	}
+}
+
+// stderrLoggers write the messages of the dispatcher itself (not the ones from Get, Put, and Check) to stderr.
+// Run uses the same logger for each severity; RunStructured writes records with the matching slog level.
+type stderrLoggers struct {
+	info, warn, error *log.Logger
+}
+
+func newStderrLoggers(logger *log.Logger) stderrLoggers {
+	return stderrLoggers{info: logger, warn: logger, error: logger}
+}
+
+// dispatch calls in, out, or check based on the command name. The logger passed to
+// them is created by newLogger, which is told whether debug output was requested.
+// Request tracing is written to traceLogger.
+func dispatch[L, ResourceParams, GetParams, PutParams, Version any](
+	customization Customization,
+	stdout io.Writer, stderr stderrLoggers, traceLogger *log.Logger, secrets *redactor, stdin io.Reader, args []string,
+	newLogger func(debug bool) L,
+	in func(context.Context, L, inRequest[ResourceParams, GetParams, Version], []string) (inResponse[Version], error),
+	out func(context.Context, L, outRequest[ResourceParams, PutParams, Version], []string) (outResponse[Version], error),
+	check func(context.Context, L, checkRequest[ResourceParams, Version], []string) (checkResponse[Version], error),
+) (err error) {
+	argv := args
+	command, args, err := parseCommand(customization, args)
+	if err != nil {
 		return err
 	}
+	switch command {
+	case commandInstall:
+		return runInstall(stderr.info, args)
+	case commandSchema:
+		return writeSchema(stdout, Schema[ResourceParams, GetParams, PutParams, Version](customization), args)
+	case commandDev:
+		return runDev(stdout, stderr.info, args, func(stdout io.Writer, stdin io.Reader, args []string) error {
+			return dispatch(customization, stdout, stderr, traceLogger, secrets, stdin, args, newLogger, in, out, check)
+		})
+	}
+	if (command == commandIn || command == commandOut) && len(args) == 0 {
+		return fmt.Errorf("%s requires a directory argument", command)
+	}
+	defer func() { err = secrets.redactError(err) }()
+	if path := customization.captureFile(); path != "" {
+		var c *capturing
+		c, stdout, stdin = startCapture(command, argv, stdout, stdin, secrets)
+		defer func() { c.finish(path, secrets, err) }()
+	}
+	ctx, stop := signalContext(context.Background(), stderr, customization.ShutdownGracePeriod)
+	defer stop()
+	defer recoverPanic(command, stderr, &err)
+	if customization.CheckReturnsRequestedVersion {
+		check = withRequestedVersion(check)
+	}
+	if customization.GetFiles != (GetFiles{}) {
+		in = withGetFiles(customization.GetFiles, stderr.warn, in)
+	}
+	inv := invocation{
+		customization: customization,
+		command:       command,
+		stdout:        stdout,
+		trace:         traceLogger,
+		secrets:       secrets,
+		stdin:         stdin,
+		args:          args,
+	}
+	switch command {
+	case commandIn:
+		return handleJSON(ctx, inv, newLogger, in)
+	case commandOut:
+		return handleJSON(ctx, inv, newLogger, out)
+	default:
+		return handleJSON(ctx, inv, newLogger, check)
+	}
 }
 
-func handleJSON[Req, Res any](ctx context.Context, bc Customization, stdout io.Writer, log *log.Logger, stdin io.Reader, args []string, run func(context.Context, *log.Logger, Req, []string) (Res, error)) error {
+// invocation is the state of a single in, out, or check call.
+type invocation struct {
+	customization Customization
+	command       string
+	stdout        io.Writer
+	trace         *log.Logger
+	secrets       *redactor
+	stdin         io.Reader
+	args          []string
+}
+
+func handleJSON[L, Req, Res any](ctx context.Context, inv invocation, newLogger func(debug bool) L, run func(context.Context, L, Req, []string) (Res, error)) error {
+	buf, err := io.ReadAll(inv.stdin)
+	if err != nil {
+		return err
+	}
+	trace := inv.tracer(buf)
 	var req Req
-	dec := json.NewDecoder(stdin)
-	if bc.DisallowUnknownFields {
-		dec.DisallowUnknownFields()
+	err = decodeRequest(buf, &req, inv.customization.DisallowUnknownFields)
+	inv.secrets.add(secretValues(&req)...)
+	if err != nil {
+		return err
+	}
+	if err := applyDefaults(buf, &req); err != nil {
+		return err
+	}
+	trace.json("request", req)
+	if err := validateRequest(&req); err != nil {
+		return err
 	}
-	err := dec.Decode(&req)
+	runCtx := ctx
+	timeout := inv.customization.timeout(inv.command)
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	res, err := callWithStdoutRedirected(inv.customization.ProtectStdout, inv.secrets, func() (Res, error) {
+		return run(runCtx, newLogger(trace.enabled), req, inv.args)
+	})
+	trace.printf("%s returned after %s", inv.command, time.Since(start))
 	if err != nil {
+		if timeout > 0 && ctx.Err() == nil && errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+			return &TimeoutError{Command: inv.command, Timeout: timeout, Err: err}
+		}
 		return err
 	}
-	res, err := run(ctx, log, req, args)
+	if r, ok := any(res).(versionResponse); ok {
+		if err := r.checkVersions(); err != nil {
+			return err
+		}
+	}
+	response, err := json.Marshal(res)
 	if err != nil {
 		return err
 	}
-	return json.NewEncoder(stdout).Encode(res)
+	trace.printf("response: %s", response)
+	_, err = inv.stdout.Write(append(response, '\n'))
+	return err
+}
+
+// sourceDebug reports whether the request has the conventional "debug": true source field.
+func sourceDebug(request []byte) bool {
+	var req struct {
+		Source struct {
+			Debug bool `json:"debug"`
+		} `json:"source"`
+	}
+	_ = json.Unmarshal(request, &req)
+	return req.Source.Debug
+}
+
+// versionResponse is implemented by the in, out, and check responses so the versions
+// can be checked before they are written.
+type versionResponse interface {
+	checkVersions() error
 }
 
 type inRequest[ResourceParams, InParams, Version any] struct {
@@ -98,11 +304,45 @@ type inResponse[Version any] struct {
 	VersionMetadata []MetadataField `json:"metadata,omitempty"`
 }
 
+func (res inResponse[Version]) checkVersions() error { return checkVersionValue(res.Version) }
+
 func (in Get[ResourceParams, GetParams, Version]) run(ctx context.Context, log *log.Logger, req inRequest[ResourceParams, GetParams, Version], args []string) (inResponse[Version], error) {
 	m, err := in(ctx, log, req.Source, req.Params, req.Version, args[0])
 	return inResponse[Version]{Version: req.Version, VersionMetadata: m}, err
 }
 
+// GetVersion is like Get but also returns the version it fetched. Use it when the requested version may be
+// partial (for example a tag without a digest or a short commit SHA) so Concourse records the canonical version.
+// When it returns the zero Version, the requested version is written.
+type GetVersion[ResourceParams, GetParams, Version any] func(context.Context, *log.Logger, ResourceParams, GetParams, Version, string) (Version, []MetadataField, error)
+
+// RunGetVersion is like Run but takes a GetVersion instead of a Get.
+func RunGetVersion[ResourceParams, GetParams, PutParams, Version any](
+	in GetVersion[ResourceParams, GetParams, Version],
+	out Put[ResourceParams, PutParams, Version],
+	check Check[ResourceParams, Version],
+) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	return RunGetVersionWithCustomization(defaultCustomization(), in, out, check)
+}
+
+// RunGetVersionWithCustomization is like RunWithCustomization but takes a GetVersion instead of a Get.
+func RunGetVersionWithCustomization[ResourceParams, GetParams, PutParams, Version any](
+	customization Customization,
+	in GetVersion[ResourceParams, GetParams, Version],
+	out Put[ResourceParams, PutParams, Version],
+	check Check[ResourceParams, Version],
+) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	return runWithLogger(customization, in.run, out, check)
+}
+
+func (in GetVersion[ResourceParams, GetParams, Version]) run(ctx context.Context, log *log.Logger, req inRequest[ResourceParams, GetParams, Version], args []string) (inResponse[Version], error) {
+	v, m, err := in(ctx, log, req.Source, req.Params, req.Version, args[0])
+	if reflect.ValueOf(&v).Elem().IsZero() {
+		v = req.Version
+	}
+	return inResponse[Version]{Version: v, VersionMetadata: m}, err
+}
+
 type outRequest[ResourceParams, PutParams, Version any] struct {
 	Source ResourceParams `json:"source"`
 	Params PutParams      `json:"params"`
@@ -113,18 +353,52 @@ type outResponse[Version any] struct {
 	VersionMetadata []MetadataField `json:"metadata"`
 }
 
+func (res outResponse[Version]) checkVersions() error { return checkVersionValue(res.Version) }
+
 func (out Put[ResourceParams, PutParams, Version]) run(ctx context.Context, log *log.Logger, req outRequest[ResourceParams, PutParams, Version], args []string) (outResponse[Version], error) {
 	v, m, err := out(ctx, log, req.Source, req.Params, args[0])
 	return outResponse[Version]{Version: v, VersionMetadata: m}, err
 }
 
 type checkRequest[ResourceParams, Version any] struct {
-	Source  ResourceParams `json:"source"`
-	Version Version        `json:"version"`
+	Source ResourceParams `json:"source"`
+
+	// Version is omitted (null) on the first check.
+	Version Version `json:"version" validate:"omitempty"`
 }
 
 type checkResponse[Version any] []Version
 
+// MarshalJSON writes an empty array instead of null when Check returns a nil slice;
+// some Concourse versions treat null as an error.
+func (res checkResponse[Version]) MarshalJSON() ([]byte, error) {
+	if res == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal([]Version(res))
+}
+
+func (res checkResponse[Version]) checkVersions() error {
+	for _, v := range res {
+		if err := checkVersionValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withRequestedVersion returns check with an empty result replaced by the requested version
+// when the request has one.
+func withRequestedVersion[L, ResourceParams, Version any](check func(context.Context, L, checkRequest[ResourceParams, Version], []string) (checkResponse[Version], error)) func(context.Context, L, checkRequest[ResourceParams, Version], []string) (checkResponse[Version], error) {
+	return func(ctx context.Context, logger L, req checkRequest[ResourceParams, Version], args []string) (checkResponse[Version], error) {
+		res, err := check(ctx, logger, req, args)
+		if err == nil && len(res) == 0 && !reflect.ValueOf(&req.Version).Elem().IsZero() {
+			res = checkResponse[Version]{req.Version}
+		}
+		return res, err
+	}
+}
+
 func (fn Check[ResourceParams, Version]) run(ctx context.Context, log *log.Logger, req checkRequest[ResourceParams, Version], _ []string) (checkResponse[Version], error) {
 	return fn(ctx, log, req.Source, req.Version)
 }
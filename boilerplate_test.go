@@ -2,38 +2,30 @@ package resource_test
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"log"
+	"os"
+	"runtime"
 	"strings"
+	"syscall"
 	"testing"
 	"testing/iotest"
+	"time"
 
 	"github.com/crhntr/resource"
 	"github.com/crhntr/resource/internal/example"
-	"github.com/crhntr/resource/internal/fakes"
+	"github.com/crhntr/resource/resourcetest"
 )
 
-//go:generate counterfeiter -generate
-//counterfeiter:generate -o ./internal/fakes/get.go --fake-name Get . getFunc
-//counterfeiter:generate -o ./internal/fakes/put.go --fake-name Put . putFunc
-//counterfeiter:generate -o ./internal/fakes/check.go --fake-name Check . checkFunc
-
 type (
-	// I need to put the config structures in another package "example" so counterfeiter can import them.
+	// The config structures are in the package "example" so other test packages can share them.
 	// In a real implementation, you can put them in the same place as the function implementations.
 
-	getFunc   resource.Get[example.Resource, example.GetParams, example.Version]
-	putFunc   resource.Put[example.Resource, example.PutParams, example.Version]
-	checkFunc resource.Check[example.Resource, example.Version]
-)
-
-var (
-	// these assignments are helpful to ensure fakes have the correct Spy signature.
-	// they also make the linter happier (the func types are flagged as unused by the linter even though they are used by counterfeiter)
-
-	_ getFunc   = new(fakes.Get).Spy
-	_ putFunc   = new(fakes.Put).Spy
-	_ checkFunc = new(fakes.Check).Spy
+	fakeGet   = resourcetest.FakeGet[example.Resource, example.GetParams, example.Version]
+	fakePut   = resourcetest.FakePut[example.Resource, example.PutParams, example.Version]
+	fakeCheck = resourcetest.FakeCheck[example.Resource, example.Version]
 )
 
 const (
@@ -70,9 +62,9 @@ const (
 func TestRun_check(t *testing.T) {
 	customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-	get := new(fakes.Get)
-	put := new(fakes.Put)
-	check := new(fakes.Check)
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
 
 	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
 
@@ -112,9 +104,9 @@ func TestRun_check(t *testing.T) {
 func TestRun_get(t *testing.T) {
 	customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-	get := new(fakes.Get)
-	put := new(fakes.Put)
-	check := new(fakes.Check)
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
 
 	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
 
@@ -161,9 +153,9 @@ func TestRun_get(t *testing.T) {
 func TestRun_put(t *testing.T) {
 	customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-	get := new(fakes.Get)
-	put := new(fakes.Put)
-	check := new(fakes.Check)
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
 
 	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
 
@@ -208,9 +200,9 @@ func TestRun_failure_cases(t *testing.T) {
 	t.Run("write to stdout fails", func(t *testing.T) {
 		customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-		get := new(fakes.Get)
-		put := new(fakes.Put)
-		check := new(fakes.Check)
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
 
 		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
 
@@ -227,9 +219,9 @@ func TestRun_failure_cases(t *testing.T) {
 	t.Run("read from stdin fails", func(t *testing.T) {
 		customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-		get := new(fakes.Get)
-		put := new(fakes.Put)
-		check := new(fakes.Check)
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
 
 		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
 
@@ -246,9 +238,9 @@ func TestRun_failure_cases(t *testing.T) {
 	t.Run("in fails", func(t *testing.T) {
 		customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-		get := new(fakes.Get)
-		put := new(fakes.Put)
-		check := new(fakes.Check)
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
 
 		get.Returns(nil, fmt.Errorf("get banana"))
 
@@ -267,9 +259,9 @@ func TestRun_failure_cases(t *testing.T) {
 	t.Run("out fails", func(t *testing.T) {
 		customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-		get := new(fakes.Get)
-		put := new(fakes.Put)
-		check := new(fakes.Check)
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
 
 		put.Returns(example.Version{}, nil, fmt.Errorf("put banana"))
 
@@ -288,9 +280,9 @@ func TestRun_failure_cases(t *testing.T) {
 	t.Run("check fails", func(t *testing.T) {
 		customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
 
-		get := new(fakes.Get)
-		put := new(fakes.Put)
-		check := new(fakes.Check)
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
 
 		check.Returns(nil, fmt.Errorf("check banana"))
 
@@ -314,9 +306,9 @@ func (errWriter) Write([]byte) (int, error) {
 }
 
 func TestRun_Logger(t *testing.T) {
-	get := new(fakes.Get)
-	put := new(fakes.Put)
-	check := new(fakes.Check)
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
 
 	mux := resource.Run(get.Spy, put.Spy, check.Spy)
 
@@ -335,3 +327,139 @@ func TestRun_Logger(t *testing.T) {
 		t.Errorf("expected default prefix")
 	}
 }
+
+func TestRun_signal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending SIGTERM is not supported on windows")
+	}
+
+	customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
+
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
+
+	check.Calls(func(ctx context.Context, _ *log.Logger, _ example.Resource, _ example.Version) ([]example.Version, error) {
+		p, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Signal(syscall.SIGTERM); err != nil {
+			return nil, err
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(5 * time.Second):
+			return nil, fmt.Errorf("context was not cancelled")
+		}
+	})
+
+	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+	stdin := strings.NewReader(checkStdin)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	err := mux(stdout, stderr, stdin, []string{"/some/absolute-path/check"})
+
+	if err != context.Canceled {
+		t.Fatalf("expected context cancelled error got %v", err)
+	}
+	if exp := "received terminated"; !strings.Contains(stderr.String(), exp) {
+		t.Errorf("expected stderr to contain %q got %q", exp, stderr.String())
+	}
+}
+
+func TestRun_timeout(t *testing.T) {
+	customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true, CheckTimeout: time.Millisecond}
+
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
+
+	check.Calls(func(ctx context.Context, _ *log.Logger, _ example.Resource, _ example.Version) ([]example.Version, error) {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	})
+
+	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+	stdin := strings.NewReader(checkStdin)
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	err := mux(stdout, stderr, stdin, []string{"/some/absolute-path/check"})
+
+	var timeoutErr *resource.TimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expected a timeout error got %v", err)
+	}
+	if exp := "check timed out after 1ms"; err.Error() != exp {
+		t.Errorf("expected error %q got %q", exp, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap the handler error")
+	}
+	if get.CallCount() != 0 || put.CallCount() != 0 {
+		t.Errorf("expected only check to be called")
+	}
+}
+
+func TestRun_checkResponse(t *testing.T) {
+	for _, tt := range []struct {
+		name          string
+		customization resource.Customization
+		stdin         string
+		versions      []example.Version
+		expected      string
+	}{
+		{name: "nil", stdin: checkStdin, expected: "[]\n"},
+		{name: "empty", stdin: checkStdin, versions: []example.Version{}, expected: "[]\n"},
+		{name: "nil with requested version", customization: resource.Customization{CheckReturnsRequestedVersion: true}, stdin: checkStdin, expected: `[{"ref":"pear"}]` + "\n"},
+		{name: "first check with requested version", customization: resource.Customization{CheckReturnsRequestedVersion: true}, stdin: `{"source": {}}`, expected: "[]\n"},
+		{name: "versions with requested version", customization: resource.Customization{CheckReturnsRequestedVersion: true}, stdin: checkStdin, versions: []example.Version{{Ref: "plum"}}, expected: `[{"ref":"plum"}]` + "\n"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			check := new(fakeCheck)
+			check.Returns(tt.versions, nil)
+			mux := resource.RunWithCustomization(tt.customization, new(fakeGet).Spy, new(fakePut).Spy, check.Spy)
+
+			stdout := new(bytes.Buffer)
+			if err := mux(stdout, new(bytes.Buffer), strings.NewReader(tt.stdin), []string{"check"}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if got := stdout.String(); got != tt.expected {
+				t.Errorf("expected %q got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestRunGetVersion(t *testing.T) {
+	get := func(_ context.Context, _ *log.Logger, _ example.Resource, _ example.GetParams, version example.Version, _ string) (example.Version, []resource.MetadataField, error) {
+		if version.Ref == "pea" {
+			return example.Version{Ref: "peach"}, []resource.MetadataField{{Key: "short", Value: version.Ref}}, nil
+		}
+		return example.Version{}, nil, nil
+	}
+	mux := resource.RunGetVersionWithCustomization(resource.Customization{}, get, new(fakePut).Spy, new(fakeCheck).Spy)
+
+	t.Run("canonical version", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		if err := mux(stdout, new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"ref": "pea"}}`), []string{"in", t.TempDir()}); err != nil {
+			t.Fatal(err)
+		}
+		if exp := `{"version":{"ref":"peach"},"metadata":[{"key":"short","value":"pea"}]}` + "\n"; stdout.String() != exp {
+			t.Errorf("expected %q got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("zero version", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		if err := mux(stdout, new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"ref": "plum"}}`), []string{"in", t.TempDir()}); err != nil {
+			t.Fatal(err)
+		}
+		if exp := `{"version":{"ref":"plum"}}` + "\n"; stdout.String() != exp {
+			t.Errorf("expected %q got %q", exp, stdout.String())
+		}
+	})
+}
@@ -0,0 +1,111 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// CaptureEnvironmentVariable may be set to a file path to enable capturing (see Customization.CaptureFile).
+const CaptureEnvironmentVariable = "RESOURCE_CAPTURE_FILE"
+
+// Capture records a single in, out, or check invocation. Secret values are redacted from Stdin, Stdout, Stderr,
+// and Error, so Stdout may differ from what Concourse received.
+// Captures are appended to the capture file as JSON lines; use ReadCaptures to read them and
+// resourcetest.Replay to turn them into a regression test.
+type Capture struct {
+	Command string            `json:"command"`
+	Args    []string          `json:"args"`
+	Env     map[string]string `json:"env,omitempty"`
+	Stdin   string            `json:"stdin"`
+	Stdout  string            `json:"stdout"`
+	Stderr  string            `json:"stderr"`
+
+	// Error is the message of the error returned by the function produced by Run.
+	Error string `json:"error,omitempty"`
+
+	// ExitStatus is the status a main function calling log.Fatal on error exits with.
+	ExitStatus int `json:"exit_status"`
+}
+
+// ReadCaptures reads the captures in a capture file.
+func ReadCaptures(r io.Reader) ([]Capture, error) {
+	var captures []Capture
+	dec := json.NewDecoder(r)
+	for {
+		var c Capture
+		err := dec.Decode(&c)
+		if err == io.EOF {
+			return captures, nil
+		}
+		if err != nil {
+			return captures, err
+		}
+		captures = append(captures, c)
+	}
+}
+
+func (c Customization) captureFile() string {
+	if path, ok := os.LookupEnv(CaptureEnvironmentVariable); ok && path != "" {
+		return path
+	}
+	return c.CaptureFile
+}
+
+// capturing tees stdin, stdout, and stderr of an invocation so they can be appended to the capture file.
+type capturing struct {
+	capture               Capture
+	stdin, stdout, stderr bytes.Buffer
+}
+
+func startCapture(command string, args []string, stdout io.Writer, stdin io.Reader, secrets *redactor) (*capturing, io.Writer, io.Reader) {
+	c := &capturing{capture: Capture{
+		Command: command,
+		Args:    args,
+		Env:     buildEnvironment(),
+	}}
+	secrets.tee(&c.stderr)
+	return c, io.MultiWriter(stdout, &c.stdout), io.TeeReader(stdin, &c.stdin)
+}
+
+// finish appends the capture to path. Failing to write the capture is logged and does not change err.
+func (c *capturing) finish(path string, secrets *redactor, err error) {
+	c.capture.Stdin = secrets.redact(c.stdin.String())
+	c.capture.Stdout = secrets.redact(c.stdout.String())
+	c.capture.Stderr = c.stderr.String()
+	if err != nil {
+		c.capture.Error = secrets.redact(err.Error())
+		c.capture.ExitStatus = 1
+	}
+	buf, marshalErr := json.Marshal(c.capture)
+	if marshalErr != nil {
+		return
+	}
+	f, openErr := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if openErr != nil {
+		_, _ = fmt.Fprintf(secrets, "failed to open capture file: %s\n", openErr)
+		return
+	}
+	defer closeAndIgnoreError(f)
+	if _, writeErr := f.Write(append(buf, '\n')); writeErr != nil {
+		_, _ = fmt.Fprintf(secrets, "failed to write capture file: %s\n", writeErr)
+	}
+}
+
+// buildEnvironment returns the environment variables Concourse sets for in and out (see Build).
+func buildEnvironment() map[string]string {
+	env := make(map[string]string)
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, "BUILD_") || key == "ATC_EXTERNAL_URL" {
+			env[key] = value
+		}
+	}
+	if len(env) == 0 {
+		return nil
+	}
+	return env
+}
@@ -0,0 +1,108 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+func TestRun_capture(t *testing.T) {
+	capturePath := filepath.Join(t.TempDir(), "captures.jsonl")
+	t.Setenv("BUILD_ID", "42")
+	t.Setenv(resource.CaptureEnvironmentVariable, "")
+
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
+	check.Returns([]example.Version{{Ref: "pear"}}, nil)
+	get.Returns(nil, fmt.Errorf("get banana"))
+
+	mux := resource.RunWithCustomization(resource.Customization{CaptureFile: capturePath}, get.Spy, put.Spy, check.Spy)
+
+	if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(checkStdin), []string{"/opt/resource/check"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(getStdin), []string{"/opt/resource/in", "some-dir"}); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	f, err := os.Open(capturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = f.Close() }()
+	captures, err := resource.ReadCaptures(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(captures) != 2 {
+		t.Fatalf("expected 2 captures got %d", len(captures))
+	}
+
+	checkCapture := captures[0]
+	if checkCapture.Command != "check" || checkCapture.Stdin != checkStdin || checkCapture.Stdout != `[{"ref":"pear"}]`+"\n" || checkCapture.ExitStatus != 0 {
+		t.Errorf("unexpected check capture %#v", checkCapture)
+	}
+	if exp := []string{"/opt/resource/check"}; len(checkCapture.Args) != 1 || checkCapture.Args[0] != exp[0] {
+		t.Errorf("expected args %q got %q", exp, checkCapture.Args)
+	}
+	if checkCapture.Env["BUILD_ID"] != "42" {
+		t.Errorf("expected BUILD_ID to be captured got %#v", checkCapture.Env)
+	}
+
+	getCapture := captures[1]
+	if getCapture.Command != "in" || getCapture.Error != "get banana" || getCapture.ExitStatus != 1 {
+		t.Errorf("unexpected in capture %#v", getCapture)
+	}
+}
+
+func TestRun_captureRedactsSecrets(t *testing.T) {
+	capturePath := filepath.Join(t.TempDir(), "captures.jsonl")
+	t.Setenv(resource.CaptureEnvironmentVariable, capturePath)
+
+	get := func(_ context.Context, _ *log.Logger, source secretTestSource, _ struct{}, _ secretTestVersion, _ string) ([]resource.MetadataField, error) {
+		return []resource.MetadataField{{Key: "token", Value: string(source.Token)}}, nil
+	}
+	mux := resource.RunWithCustomization[secretTestSource, struct{}, struct{}, secretTestVersion](resource.Customization{Debug: true}, get, nil, secretTestCheck)
+
+	if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(secretStdin), []string{"check"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	stdout := new(bytes.Buffer)
+	if err := mux(stdout, new(bytes.Buffer), strings.NewReader(secretStdin), []string{"in", t.TempDir()}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !strings.Contains(stdout.String(), "t0k3n") {
+		t.Errorf("expected the response to Concourse to keep the value got %q", stdout.String())
+	}
+
+	info, err := os.Stat(capturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0o600 {
+		t.Errorf("expected the capture file to be readable only by its owner got %s", info.Mode().Perm())
+	}
+	buf, err := os.ReadFile(capturePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(buf), "t0k3n") || strings.Contains(string(buf), "BEGIN KEY") {
+		t.Errorf("expected secrets to be redacted from %s", buf)
+	}
+	if exp := `"stdout":"{\"version\":{\"ref\":\"abc\"},\"metadata\":[{\"key\":\"token\",\"value\":\"[redacted]\"}]}\n"`; !strings.Contains(string(buf), exp) {
+		t.Errorf("expected redacted stdout %s in %s", exp, buf)
+	}
+	if exp := `debug: request:`; !strings.Contains(string(buf), exp) {
+		t.Errorf("expected stderr to be captured in %s", buf)
+	}
+}
@@ -0,0 +1,60 @@
+package resource
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	commandCheck = "check"
+	commandIn    = "in"
+	commandOut   = "out"
+)
+
+// UnknownCommandError is returned by the function produced by Run when the command name
+// (the base name of the first argument) is not one of the Expected names.
+// Name is empty when no arguments were passed.
+type UnknownCommandError struct {
+	Name     string
+	Expected []string
+}
+
+func (err *UnknownCommandError) Error() string {
+	expected := strings.Join(err.Expected, ", ")
+	if err.Name == "" {
+		return fmt.Sprintf("missing command name: expected one of %s", expected)
+	}
+	return fmt.Sprintf("unknown command %q: expected one of %s", err.Name, expected)
+}
+
+// parseCommand returns the command name and the arguments following it.
+func parseCommand(customization Customization, args []string) (string, []string, error) {
+	expected := []string{commandCheck, commandIn, commandOut}
+	if len(args) == 0 {
+		return "", nil, &UnknownCommandError{Expected: expected}
+	}
+	if len(args) > 1 && (args[1] == commandInstall || args[1] == "--"+commandInstall) {
+		return commandInstall, args[2:], nil
+	}
+	if len(args) > 1 && (args[1] == commandDev || args[1] == commandSchema) {
+		return args[1], args[2:], nil
+	}
+	name := filepath.Base(args[0])
+	if isOneOf(name, expected) {
+		return name, args[1:], nil
+	}
+	if customization.CommandFromArgument && len(args) > 1 && isOneOf(args[1], expected) {
+		return args[1], args[2:], nil
+	}
+	return "", nil, &UnknownCommandError{Name: name, Expected: expected}
+}
+
+func isOneOf(name string, list []string) bool {
+	for _, n := range list {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,112 @@
+package resource_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+func TestRun_command(t *testing.T) {
+	t.Run("unknown command", func(t *testing.T) {
+		customization := resource.Customization{}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(checkStdin), []string{"/opt/resource/chek"})
+
+		var unknownErr *resource.UnknownCommandError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("expected an unknown command error got %v", err)
+		}
+		if exp := `unknown command "chek": expected one of check, in, out`; err.Error() != exp {
+			t.Errorf("expected error %q got %q", exp, err)
+		}
+		if check.CallCount() != 0 || get.CallCount() != 0 || put.CallCount() != 0 {
+			t.Errorf("expected no function to be called")
+		}
+	})
+
+	t.Run("no arguments", func(t *testing.T) {
+		customization := resource.Customization{}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(checkStdin), nil)
+
+		if exp := "missing command name: expected one of check, in, out"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		customization := resource.Customization{}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(getStdin), []string{"/opt/resource/in"})
+
+		if exp := "in requires a directory argument"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+		if get.CallCount() != 0 {
+			t.Errorf("expected get not to be called")
+		}
+	})
+
+	t.Run("command from argument", func(t *testing.T) {
+		customization := resource.Customization{CommandFromArgument: true}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(getStdin), []string{"/bin/resource", "in", "some-dir"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got := get.CallCount(); got != 1 {
+			t.Fatalf("expected get to be called once, but it was called %d times", got)
+		}
+		if _, _, _, _, _, dir := get.ArgsForCall(0); dir != "some-dir" {
+			t.Errorf("expected directory %q got %q", "some-dir", dir)
+		}
+	})
+
+	t.Run("command from argument disabled", func(t *testing.T) {
+		customization := resource.Customization{}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(checkStdin), []string{"/bin/resource", "check"})
+
+		var unknownErr *resource.UnknownCommandError
+		if !errors.As(err, &unknownErr) {
+			t.Fatalf("expected an unknown command error got %v", err)
+		}
+		if unknownErr.Name != "resource" {
+			t.Errorf("expected name %q got %q", "resource", unknownErr.Name)
+		}
+	})
+}
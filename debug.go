@@ -0,0 +1,46 @@
+package resource
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+)
+
+// DebugEnvironmentVariable enables request and response tracing when set to a true value (see Customization.Debug).
+const DebugEnvironmentVariable = "RESOURCE_DEBUG"
+
+// tracer writes debug lines to stderr when tracing is enabled for the invocation.
+type tracer struct {
+	enabled bool
+	logger  *log.Logger
+}
+
+func (inv invocation) tracer(request []byte) tracer {
+	enabled := inv.customization.Debug || sourceDebug(request)
+	if value, ok := os.LookupEnv(DebugEnvironmentVariable); ok {
+		if b, err := strconv.ParseBool(value); err == nil && b {
+			enabled = true
+		}
+	}
+	return tracer{enabled: enabled, logger: inv.trace}
+}
+
+func (t tracer) printf(format string, v ...any) {
+	if !t.enabled {
+		return
+	}
+	t.logger.Printf("debug: "+format, v...)
+}
+
+func (t tracer) json(name string, v any) {
+	if !t.enabled {
+		return
+	}
+	buf, err := json.Marshal(v)
+	if err != nil {
+		t.printf("failed to encode %s: %s", name, err)
+		return
+	}
+	t.printf("%s: %s", name, buf)
+}
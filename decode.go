@@ -0,0 +1,297 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// DecodeError is returned by the function produced by Run when the request on stdin can not be decoded.
+type DecodeError struct {
+	// Section is the top-level request field ("source", "params", or "version") containing the problem.
+	// It is empty when the problem is not inside a section (for example a JSON syntax error).
+	Section string
+
+	// Path is the dotted JSON path of the problem, for example "source.branch".
+	Path string
+
+	// Line and Column are the 1-based position on stdin. They are zero when unknown.
+	Line, Column int
+
+	// Suggestion is a known field name similar to an unknown field.
+	Suggestion string
+
+	// Err is the error returned by encoding/json.
+	Err error
+}
+
+func (err *DecodeError) Error() string {
+	var b strings.Builder
+	b.WriteString("failed to decode request")
+	if err.Path != "" {
+		b.WriteString(" ")
+		b.WriteString(err.Path)
+	}
+	if err.Line > 0 {
+		fmt.Fprintf(&b, " (line %d, column %d)", err.Line, err.Column)
+	}
+	b.WriteString(": ")
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err.Err, &typeErr):
+		fmt.Fprintf(&b, "cannot use JSON %s as %s", typeErr.Value, typeErr.Type)
+	default:
+		b.WriteString(strings.TrimPrefix(err.Err.Error(), "json: "))
+	}
+	if err.Suggestion != "" {
+		fmt.Fprintf(&b, "; did you mean %q?", err.Suggestion)
+	}
+	return b.String()
+}
+
+func (err *DecodeError) Unwrap() error { return err.Err }
+
+// decodeRequest decodes buf into req and converts encoding/json errors into a *DecodeError.
+func decodeRequest(buf []byte, req any, disallowUnknownFields bool) error {
+	dec := json.NewDecoder(bytes.NewReader(buf))
+	if disallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	err := dec.Decode(req)
+	if err == nil {
+		return nil
+	}
+	decodeErr := &DecodeError{Err: err}
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	switch {
+	// encoding/json reports the offset after reading the offending byte or value
+	case errors.As(err, &syntaxErr):
+		decodeErr.Line, decodeErr.Column = position(buf, syntaxErr.Offset-1)
+	case errors.As(err, &typeErr):
+		decodeErr.Path = typeErr.Field
+		decodeErr.Line, decodeErr.Column = position(buf, valueStart(buf, typeErr.Offset))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		f := unknownFieldFinder{input: buf, dec: json.NewDecoder(bytes.NewReader(buf))}
+		if f.value(reflect.TypeOf(req)) {
+			decodeErr.Path = f.pathString()
+			decodeErr.Line, decodeErr.Column = position(buf, f.offset)
+			decodeErr.Suggestion = suggest(f.path[len(f.path)-1], f.known)
+		}
+	}
+	decodeErr.Section, _, _ = strings.Cut(decodeErr.Path, ".")
+	return decodeErr
+}
+
+// valueStart returns the offset of the first byte of the JSON value encoding/json read up to end.
+// For an object or array end is just after the opening delimiter; for other values it is after the last byte.
+func valueStart(buf []byte, end int64) int64 {
+	i := end - 1
+	if i < 0 || i >= int64(len(buf)) {
+		return i
+	}
+	switch buf[i] {
+	case '{', '[':
+		return i
+	case '"':
+		for i--; i >= 0; i-- {
+			if buf[i] == '"' && !escaped(buf, i) {
+				return i
+			}
+		}
+		return 0
+	}
+	for i > 0 && !bytes.ContainsRune([]byte(" \t\r\n:,["), rune(buf[i-1])) {
+		i--
+	}
+	return i
+}
+
+// escaped reports whether the byte at i is preceded by an odd number of backslashes.
+func escaped(buf []byte, i int64) bool {
+	n := 0
+	for i--; i >= 0 && buf[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// position returns the 1-based line and column of the byte at offset in buf.
+func position(buf []byte, offset int64) (line, column int) {
+	if offset < 0 || offset > int64(len(buf)) {
+		return 0, 0
+	}
+	before := buf[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	column = len(before) - bytes.LastIndexByte(before, '\n')
+	return line, column
+}
+
+// unknownFieldFinder walks JSON tokens alongside a Go type to find the first object key
+// that does not match a struct field. encoding/json does not report where it found one.
+type unknownFieldFinder struct {
+	input  []byte
+	dec    *json.Decoder
+	path   []string
+	offset int64
+	known  []string
+}
+
+// value consumes one JSON value that would be decoded into t and reports whether it contains an unknown field.
+// A nil t accepts anything.
+func (f *unknownFieldFinder) value(t reflect.Type) bool {
+	t = decodeTarget(t)
+	tok, err := f.dec.Token()
+	if err != nil {
+		return false
+	}
+	switch tok {
+	case json.Delim('{'):
+		for f.dec.More() {
+			tok, err := f.dec.Token()
+			if err != nil {
+				return false
+			}
+			key, _ := tok.(string)
+			f.path = append(f.path, key)
+			var elem reflect.Type
+			if t != nil {
+				switch t.Kind() {
+				case reflect.Struct:
+					var ok bool
+					elem, ok = structFieldType(t, key)
+					if !ok {
+						// the key token ends after its closing quote; report the position of the opening quote
+						end := f.dec.InputOffset()
+						f.offset = int64(bytes.LastIndexByte(f.input[:end-1], '"'))
+						f.known = jsonFieldNames(t)
+						return true
+					}
+				case reflect.Map:
+					elem = t.Elem()
+				}
+			}
+			if f.value(elem) {
+				return true
+			}
+			f.path = f.path[:len(f.path)-1]
+		}
+		_, _ = f.dec.Token()
+	case json.Delim('['):
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i := 0; f.dec.More(); i++ {
+			f.path = append(f.path, fmt.Sprintf("[%d]", i))
+			if f.value(elem) {
+				return true
+			}
+			f.path = f.path[:len(f.path)-1]
+		}
+		_, _ = f.dec.Token()
+	}
+	return false
+}
+
+// pathString joins the path with dots except before array indexes.
+func (f *unknownFieldFinder) pathString() string {
+	var b strings.Builder
+	for i, p := range f.path {
+		if i > 0 && !strings.HasPrefix(p, "[") {
+			b.WriteByte('.')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// decodeTarget dereferences pointers and returns nil for types that decode themselves or accept anything.
+func decodeTarget(t reflect.Type) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	for t.Kind() == reflect.Pointer {
+		if t.Implements(jsonUnmarshalerType) {
+			return nil
+		}
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Interface || reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return nil
+	}
+	return t
+}
+
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// structFieldType returns the type of the field encoding/json would decode key into.
+// Like encoding/json, it prefers an exact match and falls back to a case-insensitive one.
+func structFieldType(t reflect.Type, key string) (reflect.Type, bool) {
+	var fold reflect.Type
+	for _, field := range reflect.VisibleFields(t) {
+		name, ok := jsonFieldName(field)
+		if !ok || name == "" {
+			continue
+		}
+		if name == key {
+			return field.Type, true
+		}
+		if fold == nil && strings.EqualFold(name, key) {
+			fold = field.Type
+		}
+	}
+	return fold, fold != nil
+}
+
+func jsonFieldNames(t reflect.Type) []string {
+	var names []string
+	for _, field := range reflect.VisibleFields(t) {
+		if name, ok := jsonFieldName(field); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// suggest returns the name in known closest to name when it is close enough to be a likely typo.
+func suggest(name string, known []string) string {
+	best, bestDistance := "", -1
+	for _, k := range known {
+		d := editDistance(strings.ToLower(name), strings.ToLower(k))
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = k, d
+		}
+	}
+	if bestDistance < 0 || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
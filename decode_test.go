@@ -0,0 +1,102 @@
+package resource_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+func TestRun_decodeError(t *testing.T) {
+	for _, tt := range []struct {
+		Name      string
+		Stdin     string
+		Command   []string
+		Exp       string
+		ExpDecode resource.DecodeError
+	}{
+		{
+			Name: "unknown field",
+			Stdin: `{
+  "source": {
+    "uri": "git://some-uri",
+    "brnach": "develop"
+  },
+  "version": { "ref": "pear" }
+}`,
+			Command:   []string{"check"},
+			Exp:       `failed to decode request source.brnach (line 4, column 5): unknown field "brnach"; did you mean "branch"?`,
+			ExpDecode: resource.DecodeError{Section: "source", Path: "source.brnach", Line: 4, Column: 5, Suggestion: "branch"},
+		},
+		{
+			Name:      "unknown field without suggestion",
+			Stdin:     `{"source": {"uri": "git://some-uri"}, "params": {"include_zip": true, "unrelated": 1}, "version": {"ref": "pear"}}`,
+			Command:   []string{"in", "some-dir"},
+			Exp:       `failed to decode request params.unrelated (line 1, column 71): unknown field "unrelated"`,
+			ExpDecode: resource.DecodeError{Section: "params", Path: "params.unrelated", Line: 1, Column: 71},
+		},
+		{
+			Name:      "unknown top-level field",
+			Stdin:     `{"source": {}, "verison": {"ref": "pear"}}`,
+			Command:   []string{"check"},
+			Exp:       `failed to decode request verison (line 1, column 16): unknown field "verison"; did you mean "version"?`,
+			ExpDecode: resource.DecodeError{Section: "verison", Path: "verison", Line: 1, Column: 16, Suggestion: "version"},
+		},
+		{
+			Name: "wrong type",
+			Stdin: `{
+  "source": {"uri": "git://some-uri"},
+  "version": {"ref": 7}
+}`,
+			Command:   []string{"check"},
+			Exp:       `failed to decode request version.ref (line 3, column 22): cannot use JSON number as string`,
+			ExpDecode: resource.DecodeError{Section: "version", Path: "version.ref", Line: 3, Column: 22},
+		},
+		{
+			Name: "wrong type string value",
+			Stdin: `{
+  "source": {"uri": "git://some-uri"},
+  "params": {
+    "include_zip": "se\"ven"
+  },
+  "version": {"ref": "pear"}
+}`,
+			Command:   []string{"in", "some-dir"},
+			Exp:       `failed to decode request params.include_zip (line 4, column 20): cannot use JSON string as bool`,
+			ExpDecode: resource.DecodeError{Section: "params", Path: "params.include_zip", Line: 4, Column: 20},
+		},
+		{
+			Name:      "syntax error",
+			Stdin:     "{\n  \"source\": {,\n}",
+			Command:   []string{"check"},
+			Exp:       `failed to decode request (line 2, column 14): invalid character ',' looking for beginning of object key string`,
+			ExpDecode: resource.DecodeError{Line: 2, Column: 14},
+		},
+	} {
+		t.Run(tt.Name, func(t *testing.T) {
+			customization := resource.Customization{DisallowUnknownFields: true}
+
+			get := new(fakeGet)
+			put := new(fakePut)
+			check := new(fakeCheck)
+
+			mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+			err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(tt.Stdin), tt.Command)
+
+			var decodeErr *resource.DecodeError
+			if !errors.As(err, &decodeErr) {
+				t.Fatalf("expected a decode error got %v", err)
+			}
+			if err.Error() != tt.Exp {
+				t.Errorf("unexpected error\nexp: %s\ngot: %s", tt.Exp, err)
+			}
+			decodeErr.Err = nil
+			if *decodeErr != tt.ExpDecode {
+				t.Errorf("unexpected fields\nexp: %#v\ngot: %#v", tt.ExpDecode, *decodeErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package resource
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// Defaulter may be implemented (with a pointer receiver) by the ResourceParams, GetParams, and PutParams
+// types (or any struct they contain). Defaults is called on a zero value, and the fields it sets are used
+// when the request omits them (or sets them to null). Fields present in the request, including maps and slices,
+// are not merged with the defaults. Version is never defaulted, so the first check still receives the zero Version.
+//
+// Alternatively, add a default struct tag to a field. The tag value is parsed according to the field type:
+//
+//	string fields          the tag value is used as is
+//	time.Duration fields   parsed with time.ParseDuration (for example "2m")
+//	encoding.TextUnmarshaler implementations use UnmarshalText
+//	anything else          parsed as JSON (for example "10", "true", or `["a","b"]`)
+//
+// Default tags are applied before Defaults is called.
+// Structs reached through nil pointers are not allocated, so their defaults are not applied.
+type Defaulter interface {
+	Defaults()
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// defaultSections are the request sections defaults are applied to.
+var defaultSections = []string{"source", "params"}
+
+// applyDefaults sets the source and params fields of the decoded req that buf omits to their defaults.
+func applyDefaults(buf []byte, req any) error {
+	var sections map[string]json.RawMessage
+	_ = json.Unmarshal(buf, &sections)
+	v := reflect.ValueOf(req).Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name, ok := jsonFieldName(t.Field(i))
+		if !ok || !isOneOf(name, defaultSections) {
+			continue
+		}
+		if err := defaultStruct(v.Field(i), sections[name], name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// defaultStruct sets the fields of the decoded struct v that the JSON object raw omits to their defaults,
+// and does the same for nested structs raw has.
+func defaultStruct(v reflect.Value, raw json.RawMessage, path string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	defaults := reflect.New(v.Type()).Elem()
+	if err := defaultValue(defaults, path); err != nil {
+		return err
+	}
+	return mergeDefaults(v, defaults, raw, path)
+}
+
+func mergeDefaults(v, defaults reflect.Value, raw json.RawMessage, path string) error {
+	var present map[string]json.RawMessage
+	_ = json.Unmarshal(raw, &present)
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		fv := v.Field(i)
+		if !ok || !fv.CanSet() {
+			continue
+		}
+		if name == "" {
+			switch {
+			case fv.Kind() == reflect.Struct:
+				if err := mergeDefaults(fv, defaults.Field(i), raw, path); err != nil {
+					return err
+				}
+			case fv.IsNil():
+				fv.Set(defaults.Field(i))
+			default:
+				if err := defaultStruct(fv, raw, path); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		value, ok := lookupKey(present, name)
+		if !ok || bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+			fv.Set(defaults.Field(i))
+			continue
+		}
+		if err := defaultStruct(fv, value, joinPath(path, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lookupKey finds the value for name in an object like encoding/json matches keys to fields:
+// an exact match first, then a case-insensitive one.
+func lookupKey(object map[string]json.RawMessage, name string) (json.RawMessage, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func defaultValue(v reflect.Value, path string) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		fieldPath := path
+		if name != "" {
+			fieldPath = joinPath(path, name)
+		}
+		fv := v.Field(i)
+		if !fv.CanSet() {
+			continue
+		}
+		if tag, ok := field.Tag.Lookup("default"); ok {
+			if err := setDefault(fv, tag); err != nil {
+				return fmt.Errorf("invalid default for %s: %w", fieldPath, err)
+			}
+		}
+		if err := defaultValue(fv, fieldPath); err != nil {
+			return err
+		}
+	}
+	if v.CanAddr() && v.Addr().CanInterface() {
+		if d, ok := v.Addr().Interface().(Defaulter); ok {
+			d.Defaults()
+		}
+	}
+	return nil
+}
+
+func setDefault(v reflect.Value, tag string) error {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		v = v.Elem()
+	}
+	if v.CanAddr() {
+		if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+			return u.UnmarshalText([]byte(tag))
+		}
+	}
+	switch {
+	case v.Type() == durationType:
+		d, err := time.ParseDuration(tag)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	case v.Kind() == reflect.String:
+		v.SetString(tag)
+		return nil
+	default:
+		return json.Unmarshal([]byte(tag), v.Addr().Interface())
+	}
+}
+
+// defaultSchemaValue returns the value of a default struct tag as it would be encoded in JSON.
+func defaultSchemaValue(t reflect.Type, tag string) any {
+	v := reflect.New(t).Elem()
+	if err := setDefault(v, tag); err != nil {
+		return nil
+	}
+	return v.Interface()
+}
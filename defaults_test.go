@@ -0,0 +1,157 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crhntr/resource"
+)
+
+type defaultsTestSource struct {
+	URI     string            `json:"uri"`
+	Branch  string            `json:"branch" default:"main"`
+	Depth   int               `json:"depth" default:"1"`
+	Timeout time.Duration     `json:"timeout" default:"2m"`
+	Paths   []string          `json:"paths" default:"[\"src\"]"`
+	Shallow *bool             `json:"shallow" default:"true"`
+	Labels  map[string]string `json:"labels" default:"{\"a\":\"b\"}"`
+	Nested  struct {
+		Name string `json:"name" default:"nested"`
+	} `json:"nested"`
+}
+
+type defaultsTestParams struct {
+	Message string `json:"message"`
+}
+
+func (p *defaultsTestParams) Defaults() {
+	p.Message = "default message"
+}
+
+type defaultsTestVersion struct {
+	Ref string `json:"ref" default:"HEAD"`
+}
+
+func TestRun_defaults(t *testing.T) {
+	var (
+		gotSource defaultsTestSource
+		gotParams defaultsTestParams
+	)
+	mux := resource.RunWithCustomization(resource.Customization{DisallowUnknownFields: true},
+		func(context.Context, *log.Logger, defaultsTestSource, defaultsTestParams, defaultsTestVersion, string) ([]resource.MetadataField, error) {
+			return nil, nil
+		},
+		func(_ context.Context, _ *log.Logger, source defaultsTestSource, params defaultsTestParams, _ string) (defaultsTestVersion, []resource.MetadataField, error) {
+			gotSource, gotParams = source, params
+			return defaultsTestVersion{Ref: "abc"}, nil, nil
+		},
+		func(context.Context, *log.Logger, defaultsTestSource, defaultsTestVersion) ([]defaultsTestVersion, error) {
+			return nil, nil
+		},
+	)
+
+	t.Run("omitted", func(t *testing.T) {
+		stdin := `{"source": {"uri": "git://some-uri"}}`
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"out", "some-dir"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if gotSource.Branch != "main" || gotSource.Depth != 1 || gotSource.Timeout != 2*time.Minute ||
+			len(gotSource.Paths) != 1 || gotSource.Paths[0] != "src" ||
+			gotSource.Shallow == nil || !*gotSource.Shallow || gotSource.Nested.Name != "nested" ||
+			len(gotSource.Labels) != 1 || gotSource.Labels["a"] != "b" {
+			t.Errorf("expected defaults to be set got %#v", gotSource)
+		}
+		if exp := "default message"; gotParams.Message != exp {
+			t.Errorf("expected message %q got %q", exp, gotParams.Message)
+		}
+	})
+
+	t.Run("present", func(t *testing.T) {
+		stdin := `{"source": {"uri": "git://some-uri", "branch": "develop", "depth": 0, "shallow": false, "labels": {"c": "d"}, "nested": {}}, "params": {"message": "hello"}}`
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"out", "some-dir"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if gotSource.Branch != "develop" || gotSource.Depth != 0 || *gotSource.Shallow || gotSource.Nested.Name != "nested" ||
+			len(gotSource.Labels) != 1 || gotSource.Labels["c"] != "d" {
+			t.Errorf("expected request values to replace defaults got %#v", gotSource)
+		}
+		if exp := "hello"; gotParams.Message != exp {
+			t.Errorf("expected message %q got %q", exp, gotParams.Message)
+		}
+	})
+
+	t.Run("null", func(t *testing.T) {
+		stdin := `{"source": {"uri": "git://some-uri", "branch": null}, "params": null}`
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"out", "some-dir"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if gotSource.Branch != "main" || gotParams.Message != "default message" {
+			t.Errorf("expected null values to be defaulted got %#v %#v", gotSource, gotParams)
+		}
+	})
+
+	t.Run("first check", func(t *testing.T) {
+		var gotVersion defaultsTestVersion
+		mux := resource.RunWithCustomization[defaultsTestSource, defaultsTestParams, defaultsTestParams, defaultsTestVersion](resource.Customization{CheckReturnsRequestedVersion: true}, nil, nil,
+			func(_ context.Context, _ *log.Logger, _ defaultsTestSource, version defaultsTestVersion) ([]defaultsTestVersion, error) {
+				gotVersion = version
+				return nil, nil
+			},
+		)
+
+		stdout := new(bytes.Buffer)
+		if err := mux(stdout, new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": null}`), []string{"check"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if gotVersion != (defaultsTestVersion{}) {
+			t.Errorf("expected the zero version got %#v", gotVersion)
+		}
+		if exp := "[]\n"; stdout.String() != exp {
+			t.Errorf("expected stdout %q got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("invalid default", func(t *testing.T) {
+		type source struct {
+			Depth int `json:"depth" default:"one"`
+		}
+		mux := resource.RunWithCustomization(resource.Customization{},
+			func(context.Context, *log.Logger, source, struct{}, defaultsTestVersion, string) ([]resource.MetadataField, error) {
+				return nil, nil
+			},
+			func(context.Context, *log.Logger, source, struct{}, string) (defaultsTestVersion, []resource.MetadataField, error) {
+				return defaultsTestVersion{}, nil, nil
+			},
+			func(context.Context, *log.Logger, source, defaultsTestVersion) ([]defaultsTestVersion, error) {
+				return nil, nil
+			},
+		)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(`{}`), []string{"check"})
+
+		if exp := "invalid default for source.depth"; err == nil || !strings.Contains(err.Error(), exp) {
+			t.Errorf("expected error containing %q got %v", exp, err)
+		}
+	})
+}
+
+func TestSchema_default(t *testing.T) {
+	schema := resource.Schema[defaultsTestSource, defaultsTestParams, defaultsTestParams, defaultsTestVersion](resource.Customization{})
+
+	buf, err := json.Marshal(schema.Defs["source"].Properties["branch"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exp := `{"type":"string","default":"main"}`; string(buf) != exp {
+		t.Errorf("expected %s got %s", exp, buf)
+	}
+}
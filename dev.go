@@ -0,0 +1,128 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const commandDev = "dev"
+
+// runDev handles "dev COMMAND [flags]". It builds the request from flags instead of stdin,
+// calls run with it, and writes the response as indented JSON to stdout.
+// It lets you try a resource locally without a Concourse deployment:
+//
+//	my-resource dev check -source source.json -version ref=abc
+//	my-resource dev in -source uri=git://example.com -params include_zip=true
+func runDev(stdout io.Writer, stderr *log.Logger, args []string, run func(stdout io.Writer, stdin io.Reader, args []string) error) error {
+	if len(args) == 0 || !isOneOf(args[0], []string{commandCheck, commandIn, commandOut}) {
+		return fmt.Errorf("%s requires one of %s, %s, or %s as the first argument", commandDev, commandCheck, commandIn, commandOut)
+	}
+	command := args[0]
+
+	flags := flag.NewFlagSet(commandDev+" "+command, flag.ContinueOnError)
+	flags.SetOutput(stderr.Writer())
+	source := requestField{values: make(map[string]any)}
+	params := requestField{values: make(map[string]any)}
+	version := requestField{values: make(map[string]any), stringsOnly: true}
+	flags.Var(&source, "source", "a JSON object `file` or a key=value pair to merge into source (may be repeated)")
+	if command != commandCheck {
+		flags.Var(&params, "params", "a JSON object `file` or a key=value pair to merge into params (may be repeated)")
+	}
+	if command != commandOut {
+		flags.Var(&version, "version", "a JSON object `file` or a key=value pair to merge into version (may be repeated)")
+	}
+	dir := flags.String("dir", "", "the directory passed to in or out (default a new temporary directory)")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if flags.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(flags.Args(), " "))
+	}
+
+	request := map[string]any{"source": source.values}
+	if command != commandCheck {
+		request["params"] = params.values
+	}
+	if command != commandOut {
+		request["version"] = version.values
+	}
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	runArgs := []string{command}
+	if command != commandCheck {
+		if *dir == "" {
+			*dir, err = os.MkdirTemp("", "resource-"+command+"-")
+			if err != nil {
+				return err
+			}
+		}
+		stderr.Printf("directory: %s", *dir)
+		runArgs = append(runArgs, *dir)
+	}
+
+	var response bytes.Buffer
+	if err := run(&response, bytes.NewReader(stdin), runArgs); err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, response.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	_, err = indented.WriteTo(stdout)
+	return err
+}
+
+// requestField is a flag.Value that accumulates values from JSON files and key=value pairs.
+type requestField struct {
+	values map[string]any
+
+	// stringsOnly disables parsing key=value values as JSON; Concourse versions only have string values.
+	stringsOnly bool
+}
+
+func (f *requestField) String() string {
+	if f == nil || len(f.values) == 0 {
+		return ""
+	}
+	buf, _ := json.Marshal(f.values)
+	return string(buf)
+}
+
+func (f *requestField) Set(s string) error {
+	if key, value, ok := strings.Cut(s, "="); ok {
+		if key == "" {
+			return fmt.Errorf("missing key in %q", s)
+		}
+		f.values[key] = value
+		var v any
+		if !f.stringsOnly && json.Unmarshal([]byte(value), &v) == nil {
+			f.values[key] = v
+		}
+		return nil
+	}
+	if ext := strings.ToLower(filepath.Ext(s)); ext == ".yml" || ext == ".yaml" {
+		return fmt.Errorf("YAML is not supported, convert %s to JSON", s)
+	}
+	buf, err := os.ReadFile(s)
+	if err != nil {
+		return err
+	}
+	var values map[string]any
+	if err := json.Unmarshal(buf, &values); err != nil {
+		return fmt.Errorf("failed to parse %s as a JSON object: %w", s, err)
+	}
+	for key, value := range values {
+		f.values[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package resource_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+func TestRun_dev(t *testing.T) {
+	t.Run("check", func(t *testing.T) {
+		customization := resource.Customization{DisallowUnknownFields: true}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+		check.Returns([]example.Version{{Ref: "abc"}, {Ref: "def"}}, nil)
+
+		sourceFile := filepath.Join(t.TempDir(), "source.json")
+		if err := os.WriteFile(sourceFile, []byte(`{"uri": "git://some-uri", "branch": "main"}`), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		stdout := new(bytes.Buffer)
+		err := mux(stdout, new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "dev", "check", "-source", sourceFile, "-source", "branch=develop", "-version", "ref=123"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if got := check.CallCount(); got != 1 {
+			t.Fatalf("expected check to be called once, but it was called %d times", got)
+		}
+		_, _, source, version := check.ArgsForCall(0)
+		if exp := (example.Resource{URI: "git://some-uri", Branch: "develop"}); source != exp {
+			t.Errorf("expected source %#v got %#v", exp, source)
+		}
+		if exp := "123"; version.Ref != exp {
+			t.Errorf("expected version ref %q got %q", exp, version.Ref)
+		}
+		if exp := "[\n  {\n    \"ref\": \"abc\"\n  },\n  {\n    \"ref\": \"def\"\n  }\n]\n"; stdout.String() != exp {
+			t.Errorf("expected stdout %q got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("in", func(t *testing.T) {
+		customization := resource.Customization{DisallowUnknownFields: true}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+		get.Returns([]resource.MetadataField{{Key: "commit", Value: "abc"}}, nil)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		err := mux(stdout, stderr, strings.NewReader(""), []string{"/bin/resource", "dev", "in", "-params", "include_zip=true", "-version", "ref=abc"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		_, _, _, params, _, dir := get.ArgsForCall(0)
+		t.Cleanup(func() { _ = os.RemoveAll(dir) })
+		if !params.IncludeZip {
+			t.Errorf("expected params to be parsed")
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("expected a temporary directory to be created got %q", dir)
+		}
+		if exp := "directory: " + dir; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected stderr to contain %q got %q", exp, stderr.String())
+		}
+		if exp := "\"key\": \"commit\""; !strings.Contains(stdout.String(), exp) {
+			t.Errorf("expected stdout to contain %q got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("out with directory", func(t *testing.T) {
+		customization := resource.Customization{DisallowUnknownFields: true}
+
+		get := new(fakeGet)
+		put := new(fakePut)
+		check := new(fakeCheck)
+
+		mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+		dir := t.TempDir()
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "dev", "out", "-dir", dir, "-params", "ensure_checksum=true"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		_, _, _, params, gotDir := put.ArgsForCall(0)
+		if gotDir != dir {
+			t.Errorf("expected directory %q got %q", dir, gotDir)
+		}
+		if !params.EnsureChecksum {
+			t.Errorf("expected params to be parsed")
+		}
+	})
+
+	t.Run("missing command", func(t *testing.T) {
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "dev"})
+
+		if exp := "dev requires one of check, in, or out as the first argument"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+	})
+
+	t.Run("check has no params", func(t *testing.T) {
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "dev", "check", "-params", "a=b"})
+
+		if exp := "flag provided but not defined: -params"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+	})
+
+	t.Run("yaml file", func(t *testing.T) {
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "dev", "check", "-source", "source.yml"})
+
+		if exp := `invalid value "source.yml" for flag -source: YAML is not supported, convert source.yml to JSON`; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+	})
+}
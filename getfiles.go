@@ -0,0 +1,115 @@
+package resource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GetFiles configures the files the function returned by Run writes to the Get directory after Get succeeds,
+// so later steps in the build can read the version and metadata. Names are relative to the directory;
+// empty names are skipped.
+type GetFiles struct {
+	// Version is the name of a file the version is written to as a JSON object, for example "version".
+	Version string
+
+	// Metadata is the name of a file the metadata is written to as a JSON array of
+	// {"key": ..., "value": ...} objects, for example "metadata.json".
+	Metadata string
+
+	// VersionFields is a directory (use "." for the Get directory itself) where each version field is
+	// written to a plain file named after the field, for example "ref" containing the commit SHA.
+	VersionFields string
+
+	// MetadataFields is a directory where each metadata field is written to a plain file named after its key.
+	// Fields whose key is not a valid file name (for example one containing a slash) are skipped with a log line.
+	MetadataFields string
+}
+
+// DefaultGetFiles writes the version to "version" and the metadata to "metadata.json".
+var DefaultGetFiles = GetFiles{
+	Version:  "version",
+	Metadata: "metadata.json",
+}
+
+// withGetFiles returns in with files written to the directory argument after it succeeds.
+// Nothing is written when the version would be rejected by handleJSON.
+func withGetFiles[L, ResourceParams, GetParams, Version any](files GetFiles, stderr *log.Logger, in func(context.Context, L, inRequest[ResourceParams, GetParams, Version], []string) (inResponse[Version], error)) func(context.Context, L, inRequest[ResourceParams, GetParams, Version], []string) (inResponse[Version], error) {
+	return func(ctx context.Context, logger L, req inRequest[ResourceParams, GetParams, Version], args []string) (inResponse[Version], error) {
+		res, err := in(ctx, logger, req, args)
+		if err != nil {
+			return res, err
+		}
+		if err := res.checkVersions(); err != nil {
+			return res, err
+		}
+		return res, files.write(stderr, args[0], res.Version, res.VersionMetadata)
+	}
+}
+
+func (files GetFiles) write(stderr *log.Logger, dir string, version any, metadata []MetadataField) error {
+	versionJSON, err := json.Marshal(version)
+	if err != nil {
+		return err
+	}
+	if files.Version != "" {
+		if err := writeGetFile(dir, files.Version, append(versionJSON, '\n')); err != nil {
+			return err
+		}
+	}
+	if files.Metadata != "" {
+		if metadata == nil {
+			metadata = []MetadataField{}
+		}
+		buf, err := json.MarshalIndent(metadata, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := writeGetFile(dir, files.Metadata, append(buf, '\n')); err != nil {
+			return err
+		}
+	}
+	if files.VersionFields != "" {
+		var fields map[string]string
+		if err := json.Unmarshal(versionJSON, &fields); err != nil {
+			return fmt.Errorf("failed to write version fields: %w", err)
+		}
+		for key, value := range fields {
+			if err := writeFieldFile(stderr, dir, files.VersionFields, key, value); err != nil {
+				return err
+			}
+		}
+	}
+	if files.MetadataFields != "" {
+		for _, field := range metadata {
+			if err := writeFieldFile(stderr, dir, files.MetadataFields, field.Key, field.Value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeFieldFile writes value to a file named key. Keys that are not a valid file name are skipped.
+func writeFieldFile(stderr *log.Logger, dir, fieldsDir, key, value string) error {
+	if key == "" || key == "." || key == ".." || strings.ContainsAny(key, `/\`) {
+		stderr.Printf("skipping field %q: the key is not a valid file name", key)
+		return nil
+	}
+	return writeGetFile(dir, filepath.Join(fieldsDir, key), []byte(value))
+}
+
+func writeGetFile(dir, name string, data []byte) error {
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
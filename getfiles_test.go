@@ -0,0 +1,128 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+func TestRun_getFiles(t *testing.T) {
+	readFile := func(t *testing.T, path string) string {
+		t.Helper()
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(buf)
+	}
+
+	t.Run("default", func(t *testing.T) {
+		get := new(fakeGet)
+		get.Returns([]resource.MetadataField{{Key: "author", Value: "someone"}}, nil)
+		mux := resource.RunWithCustomization(resource.Customization{GetFiles: resource.DefaultGetFiles}, get.Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		dir := t.TempDir()
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(getStdin), []string{"in", dir}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got, exp := readFile(t, filepath.Join(dir, "version")), `{"ref":"peach"}`+"\n"; got != exp {
+			t.Errorf("expected version file %q got %q", exp, got)
+		}
+		if got, exp := readFile(t, filepath.Join(dir, "metadata.json")), "[\n  {\n    \"key\": \"author\",\n    \"value\": \"someone\"\n  }\n]\n"; got != exp {
+			t.Errorf("expected metadata file %q got %q", exp, got)
+		}
+	})
+
+	t.Run("field files", func(t *testing.T) {
+		get := new(fakeGet)
+		get.Returns([]resource.MetadataField{{Key: "author", Value: "someone"}}, nil)
+		mux := resource.RunWithCustomization(resource.Customization{GetFiles: resource.GetFiles{
+			Metadata:       "meta/all.json",
+			VersionFields:  ".",
+			MetadataFields: "meta",
+		}}, get.Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		dir := t.TempDir()
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(getStdin), []string{"in", dir}); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := readFile(t, filepath.Join(dir, "ref")); got != "peach" {
+			t.Errorf("expected ref file %q got %q", "peach", got)
+		}
+		if got := readFile(t, filepath.Join(dir, "meta", "author")); got != "someone" {
+			t.Errorf("expected author file %q got %q", "someone", got)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "meta", "all.json")); err != nil {
+			t.Errorf("expected metadata file: %s", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "version")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected no version file")
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		get := new(fakeGet)
+		get.Returns([]resource.MetadataField{{Key: "../escape", Value: "x"}, {Key: "author", Value: "someone"}}, nil)
+		mux := resource.RunWithCustomization(resource.Customization{GetFiles: resource.GetFiles{MetadataFields: "meta"}}, get.Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		dir := t.TempDir()
+		stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+		if err := mux(stdout, stderr, strings.NewReader(getStdin), []string{"in", dir}); err != nil {
+			t.Fatal(err)
+		}
+
+		if exp := `skipping field "../escape": the key is not a valid file name`; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected log %q got %q", exp, stderr.String())
+		}
+		if _, err := os.Stat(filepath.Join(dir, "escape")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("expected no file outside the fields directory")
+		}
+		if got := readFile(t, filepath.Join(dir, "meta", "author")); got != "someone" {
+			t.Errorf("expected author file %q got %q", "someone", got)
+		}
+		if stdout.Len() == 0 {
+			t.Errorf("expected a response")
+		}
+	})
+
+	t.Run("invalid version", func(t *testing.T) {
+		get := func(context.Context, *log.Logger, struct{}, struct{}, numberVersion, string) ([]resource.MetadataField, error) {
+			return nil, nil
+		}
+		mux := resource.RunWithCustomization[struct{}, struct{}, struct{}, numberVersion](resource.Customization{GetFiles: resource.DefaultGetFiles}, get, nil, nil)
+
+		dir := t.TempDir()
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"Ref": "a"}}`), []string{"in", dir})
+
+		var versionErr *resource.VersionError
+		if !errors.As(err, &versionErr) {
+			t.Fatalf("expected a *resource.VersionError got %v", err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("expected no files got %v", entries)
+		}
+	})
+
+	t.Run("get fails", func(t *testing.T) {
+		get := new(fakeGet)
+		get.Returns(nil, errors.New("banana"))
+		mux := resource.RunWithCustomization(resource.Customization{GetFiles: resource.DefaultGetFiles}, get.Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		dir := t.TempDir()
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(getStdin), []string{"in", dir}); err == nil {
+			t.Fatal("expected an error")
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("expected no files got %v", entries)
+		}
+	})
+}
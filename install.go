@@ -0,0 +1,134 @@
+package resource
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	commandInstall = "install"
+
+	// DefaultInstallDirectory is where Concourse looks for the in, out, and check executables.
+	DefaultInstallDirectory = "/opt/resource"
+)
+
+// runInstall handles "install [-copy] [DIR]" by linking (or copying) the running executable
+// to DIR/in, DIR/out, and DIR/check so a Dockerfile only needs
+//
+//	RUN /bin/my-resource install
+func runInstall(stderr *log.Logger, args []string) error {
+	flags := flag.NewFlagSet(commandInstall, flag.ContinueOnError)
+	flags.SetOutput(stderr.Writer())
+	copyExecutable := flags.Bool("copy", false, "copy the executable instead of creating symbolic links")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	dir := DefaultInstallDirectory
+	switch flags.NArg() {
+	case 0:
+	case 1:
+		dir = flags.Arg(0)
+	default:
+		return fmt.Errorf("%s accepts at most one directory argument", commandInstall)
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		return err
+	}
+	return install(stderr, executable, dir, *copyExecutable)
+}
+
+func install(stderr *log.Logger, executable, dir string, copyExecutable bool) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, name := range []string{commandCheck, commandIn, commandOut} {
+		p := filepath.Join(dir, name)
+		if same, err := sameFile(p, executable, copyExecutable); err != nil {
+			return err
+		} else if same {
+			stderr.Printf("%s is up to date", p)
+			continue
+		}
+		var err error
+		if copyExecutable {
+			err = copyFile(p, executable)
+		} else {
+			err = symlink(p, executable)
+		}
+		if err != nil {
+			return err
+		}
+		stderr.Printf("installed %s", p)
+	}
+	return nil
+}
+
+// sameFile reports whether p exists and is already the executable (or a symbolic link to it).
+// A copy is never considered the same so that reinstalling with -copy updates it, and with copyExecutable
+// a symbolic link is never considered the same so that it is replaced with a copy.
+func sameFile(p, executable string, copyExecutable bool) (bool, error) {
+	info, err := os.Lstat(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		if copyExecutable {
+			return false, nil
+		}
+		target, err := os.Readlink(p)
+		return err == nil && target == executable, err
+	}
+	executableInfo, err := os.Stat(executable)
+	if err != nil {
+		return false, err
+	}
+	return os.SameFile(info, executableInfo), nil
+}
+
+func symlink(p, executable string) error {
+	if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return os.Symlink(executable, p)
+}
+
+func copyFile(p, executable string) error {
+	src, err := os.Open(executable)
+	if err != nil {
+		return err
+	}
+	defer closeAndIgnoreError(src)
+	tmp, err := os.CreateTemp(filepath.Dir(p), "."+filepath.Base(p)+"-*")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := io.Copy(tmp, src); err != nil {
+		closeAndIgnoreError(tmp)
+		return err
+	}
+	if err := tmp.Chmod(0o755); err != nil {
+		closeAndIgnoreError(tmp)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p)
+}
+
+func closeAndIgnoreError(c io.Closer) { _ = c.Close() }
@@ -0,0 +1,122 @@
+package resource_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+func TestRun_install(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("symbolic links require additional privileges on windows")
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	executable, err = filepath.EvalSymlinks(executable)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("symlinks", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "opt", "resource")
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		for i := 0; i < 2; i++ {
+			stderr := new(bytes.Buffer)
+			if err := mux(new(bytes.Buffer), stderr, strings.NewReader(""), []string{"/bin/resource", "install", dir}); err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if i == 1 {
+				if exp := "up to date"; !strings.Contains(stderr.String(), exp) {
+					t.Errorf("expected second install to log %q got %q", exp, stderr.String())
+				}
+			}
+		}
+
+		for _, name := range []string{"in", "out", "check"} {
+			target, err := os.Readlink(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if target != executable {
+				t.Errorf("expected %s to link to %q got %q", name, executable, target)
+			}
+		}
+	})
+
+	t.Run("copy", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "in"), []byte("stale"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "--install", "-copy", dir}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		executableInfo, err := os.Stat(executable)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, name := range []string{"in", "out", "check"} {
+			info, err := os.Lstat(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.Mode().IsRegular() || info.Mode().Perm() != 0o755 {
+				t.Errorf("expected %s to be an executable file got mode %s", name, info.Mode())
+			}
+			if info.Size() != executableInfo.Size() {
+				t.Errorf("expected %s to be a copy of the executable", name)
+			}
+		}
+	})
+
+	t.Run("symlinks then copy", func(t *testing.T) {
+		dir := t.TempDir()
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "install", dir}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		stderr := new(bytes.Buffer)
+		if err := mux(new(bytes.Buffer), stderr, strings.NewReader(""), []string{"/bin/resource", "install", "-copy", dir}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if strings.Contains(stderr.String(), "up to date") {
+			t.Errorf("expected the symbolic links to be replaced got %q", stderr.String())
+		}
+		for _, name := range []string{"in", "out", "check"} {
+			info, err := os.Lstat(filepath.Join(dir, name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.Mode().IsRegular() {
+				t.Errorf("expected %s to be a copy got mode %s", name, info.Mode())
+			}
+		}
+		if _, err := os.Stat(executable); err != nil {
+			t.Errorf("expected the executable to be left in place: %s", err)
+		}
+	})
+
+	t.Run("too many arguments", func(t *testing.T) {
+		mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "install", "a", "b"})
+
+		if exp := "install accepts at most one directory argument"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+	})
+}
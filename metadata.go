@@ -0,0 +1,311 @@
+package resource
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// DefaultMetadataValueLength is the default MetadataBuilder.MaxValueLength.
+	DefaultMetadataValueLength = 4 << 10
+
+	// DefaultMetadataLength is the default MetadataBuilder.MaxLength.
+	DefaultMetadataLength = 16 << 10
+)
+
+// MetadataBuilder collects metadata fields for Get and Put to return.
+// Fields keep the order they were first added in; adding a key again replaces its value.
+// Concourse stores metadata in its database with every version, so Fields truncates long values and
+// drops fields that do not fit the limits.
+//
+//	metadata := resource.MetadataBuilder{Logger: logger}
+//	metadata.String("commit", sha).Time("committed", commit.When).URL("url", commitURL)
+//	return metadata.Fields(), nil
+//
+// The zero value is ready to use.
+type MetadataBuilder struct {
+	// MaxValueLength is the maximum length in bytes of a value. Longer values are truncated.
+	// The zero value means DefaultMetadataValueLength; a negative value disables truncation.
+	MaxValueLength int
+
+	// MaxLength is the maximum length in bytes of all keys and values. Fields that do not fit are dropped.
+	// The zero value means DefaultMetadataLength; a negative value disables the limit.
+	MaxLength int
+
+	// Logger, when set, is used to explain truncated and dropped fields.
+	// Pass the logger Get or Put receives so the note is written to stderr.
+	Logger *log.Logger
+
+	fields []MetadataField
+	index  map[string]int
+}
+
+// String adds a field with value.
+func (b *MetadataBuilder) String(key, value string) *MetadataBuilder {
+	if b.index == nil {
+		b.index = make(map[string]int)
+	}
+	if i, ok := b.index[key]; ok {
+		b.fields[i].Value = value
+		return b
+	}
+	b.index[key] = len(b.fields)
+	b.fields = append(b.fields, MetadataField{Key: key, Value: value})
+	return b
+}
+
+// Int adds a field with a decimal integer value.
+func (b *MetadataBuilder) Int(key string, value int64) *MetadataBuilder {
+	return b.String(key, strconv.FormatInt(value, 10))
+}
+
+// Bool adds a field with the value "true" or "false".
+func (b *MetadataBuilder) Bool(key string, value bool) *MetadataBuilder {
+	return b.String(key, strconv.FormatBool(value))
+}
+
+// Time adds a field with the value formatted as RFC 3339 in UTC. The zero time is skipped.
+func (b *MetadataBuilder) Time(key string, value time.Time) *MetadataBuilder {
+	if value.IsZero() {
+		return b
+	}
+	return b.String(key, value.UTC().Format(time.RFC3339))
+}
+
+// Duration adds a field with the value formatted by time.Duration.String.
+func (b *MetadataBuilder) Duration(key string, value time.Duration) *MetadataBuilder {
+	return b.String(key, value.String())
+}
+
+// Size adds a field with a byte count formatted with binary units, for example "1.5 MiB".
+func (b *MetadataBuilder) Size(key string, bytes int64) *MetadataBuilder {
+	return b.String(key, formatSize(bytes))
+}
+
+// URL adds a field with the URL. Any password is replaced with "xxxxx". A nil URL is skipped.
+func (b *MetadataBuilder) URL(key string, value *url.URL) *MetadataBuilder {
+	if value == nil {
+		return b
+	}
+	return b.String(key, value.Redacted())
+}
+
+// Fields returns the fields with the limits applied.
+func (b *MetadataBuilder) Fields() []MetadataField {
+	maxValue := limit(b.MaxValueLength, DefaultMetadataValueLength)
+	maxTotal := limit(b.MaxLength, DefaultMetadataLength)
+	fields := make([]MetadataField, 0, len(b.fields))
+	total := 0
+	for _, field := range b.fields {
+		if maxValue >= 0 && len(field.Value) > maxValue {
+			b.logf("metadata field %q truncated from %d to %d bytes", field.Key, len(field.Value), maxValue)
+			field.Value = truncate(field.Value, maxValue)
+		}
+		size := len(field.Key) + len(field.Value)
+		if maxTotal >= 0 && total+size > maxTotal {
+			b.logf("metadata field %q dropped: metadata would exceed %d bytes", field.Key, maxTotal)
+			continue
+		}
+		total += size
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+func (b *MetadataBuilder) logf(format string, v ...any) {
+	if b.Logger != nil {
+		b.Logger.Printf(format, v...)
+	}
+}
+
+func limit(n, def int) int {
+	if n == 0 {
+		return def
+	}
+	return n
+}
+
+const truncationMarker = "…"
+
+// truncate shortens s to at most n bytes (including the marker) without splitting a UTF-8 sequence.
+func truncate(s string, n int) string {
+	if n < len(truncationMarker) {
+		return ""
+	}
+	end := n - len(truncationMarker)
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + truncationMarker
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit && bytes > -unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	value := float64(bytes)
+	for _, suffix := range []string{"KiB", "MiB", "GiB", "TiB", "PiB"} {
+		value /= unit
+		if value < unit && value > -unit || suffix == "PiB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return ""
+}
+
+// MetadataFrom returns metadata fields for the struct (or pointer to struct) v with the default limits.
+// Truncated and dropped fields are explained with logger, which may be nil to discard the notes.
+// See MetadataBuilder.Struct for the struct tags it reads.
+//
+//	type result struct {
+//		Commit string        `metadata:"commit"`
+//		Size   int64         `metadata:"size,size"`
+//		Took   time.Duration `metadata:"took,omitempty"`
+//	}
+//
+//	return resource.MetadataFrom(logger, result{...}), nil
+func MetadataFrom(logger *log.Logger, v any) []MetadataField {
+	b := MetadataBuilder{Logger: logger}
+	return b.Struct(v).Fields()
+}
+
+// Struct adds a field for each field of the struct (or pointer to struct) v with a metadata tag,
+// in the order the fields are declared. The tag value is the key followed by comma separated options:
+//
+//	omitempty  skips the field when it has the zero value
+//	size       formats an integer as a byte count (like Size)
+//
+// Values are formatted like the other MetadataBuilder methods by type: time.Time, time.Duration, and *url.URL
+// values use Time, Duration, and URL; fmt.Stringer implementations (including Secret, which is redacted) use String;
+// slices of strings are joined with ", "; other structs, maps, and slices are encoded as JSON.
+// Nil pointers, maps, and slices are skipped. Fields of exported embedded structs without a metadata tag are added in place.
+func (b *MetadataBuilder) Struct(v any) *MetadataBuilder {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return b
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return b
+	}
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, tagged := field.Tag.Lookup("metadata")
+		fv := rv.Field(i)
+		if !tagged {
+			if field.Anonymous && fv.CanInterface() {
+				b.Struct(fv.Interface())
+			}
+			continue
+		}
+		key, options, _ := strings.Cut(tag, ",")
+		if key == "-" || !field.IsExported() {
+			continue
+		}
+		if key == "" {
+			key = field.Name
+		}
+		if hasOption(options, "omitempty") && fv.IsZero() {
+			continue
+		}
+		b.value(key, fv, hasOption(options, "size"))
+	}
+	return b
+}
+
+func (b *MetadataBuilder) value(key string, v reflect.Value, size bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		if u, ok := v.Interface().(*url.URL); ok {
+			b.URL(key, u)
+			return
+		}
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return
+	}
+	switch value := v.Interface().(type) {
+	case time.Time:
+		b.Time(key, value)
+		return
+	case time.Duration:
+		b.Duration(key, value)
+		return
+	case url.URL:
+		b.URL(key, &value)
+		return
+	case fmt.Stringer:
+		b.String(key, value.String())
+		return
+	case encoding.TextMarshaler:
+		if text, err := value.MarshalText(); err == nil {
+			b.String(key, string(text))
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.String:
+		b.String(key, v.String())
+	case reflect.Bool:
+		b.Bool(key, v.Bool())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if size {
+			b.Size(key, v.Int())
+		} else {
+			b.Int(key, v.Int())
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if size {
+			b.Size(key, int64(v.Uint()))
+		} else {
+			b.String(key, strconv.FormatUint(v.Uint(), 10))
+		}
+	case reflect.Float32, reflect.Float64:
+		b.String(key, strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits()))
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.String {
+			values := make([]string, v.Len())
+			for i := range values {
+				values[i] = v.Index(i).String()
+			}
+			b.String(key, strings.Join(values, ", "))
+			return
+		}
+		b.json(key, v)
+	default:
+		b.json(key, v)
+	}
+}
+
+func (b *MetadataBuilder) json(key string, v reflect.Value) {
+	buf, err := json.Marshal(v.Interface())
+	if err != nil {
+		b.String(key, fmt.Sprint(v.Interface()))
+		return
+	}
+	b.String(key, string(buf))
+}
+
+func hasOption(options, option string) bool {
+	for _, o := range strings.Split(options, ",") {
+		if o == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,65 @@
+package resource
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime/debug"
+)
+
+// PanicError is returned by the function produced by Run when Get, Put, or Check
+// (or a Defaults or Validate method) panics. The stack trace is also written to stderr.
+type PanicError struct {
+	Command string
+	Value   any
+
+	// Stack is the stack trace of the panicking goroutine, starting where panic was called
+	// and ending before the dispatcher frames.
+	Stack []byte
+}
+
+func (err *PanicError) Error() string {
+	return fmt.Sprintf("%s panicked: %v", err.Command, err.Value)
+}
+
+// Unwrap returns the panic value when it is an error.
+func (err *PanicError) Unwrap() error {
+	e, _ := err.Value.(error)
+	return e
+}
+
+// recoverPanic must be deferred. It converts a panic into a *PanicError assigned to err and logs the stack trace.
+func recoverPanic(command string, stderr stderrLoggers, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	panicErr := &PanicError{Command: command, Value: r, Stack: trimStack(debug.Stack())}
+	stderr.error.Printf("%s\n%s", panicErr, panicErr.Stack)
+	*err = panicErr
+}
+
+var handleJSONFrame = []byte(reflect.TypeOf(PanicError{}).PkgPath() + ".handleJSON[")
+
+// trimStack removes the frames before the call to panic (debug.Stack, recoverPanic, and the runtime)
+// and the frames from handleJSON on, leaving the frames a resource author cares about.
+func trimStack(stack []byte) []byte {
+	lines := bytes.SplitAfter(stack, []byte("\n"))
+	start, end := 0, len(lines)
+	for i, line := range lines {
+		if bytes.HasPrefix(line, []byte("panic(")) {
+			start = i + 2 // skip the function line and its file:line
+			break
+		}
+	}
+	for i := start; i < len(lines); i++ {
+		if bytes.HasPrefix(lines[i], handleJSONFrame) {
+			end = i
+			break
+		}
+	}
+	if start >= end {
+		return stack
+	}
+	return bytes.Join(lines[start:end], nil)
+}
@@ -0,0 +1,64 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+func TestRun_panic(t *testing.T) {
+	customization := resource.Customization{LoggerPrefix: "", LoggerFlags: 0, DisallowUnknownFields: true}
+
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
+
+	check.Calls(panickingCheck)
+
+	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	err := mux(stdout, stderr, strings.NewReader(checkStdin), []string{"/some/absolute-path/check"})
+
+	var panicErr *resource.PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("expected a panic error got %v", err)
+	}
+	if exp := "check panicked: assignment to entry in nil map"; err.Error() != exp {
+		t.Errorf("expected error %q got %q", exp, err)
+	}
+	var runtimeErr runtime.Error
+	if !errors.As(err, &runtimeErr) {
+		t.Errorf("expected error to wrap the runtime error")
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no output on stdout got %q", stdout.String())
+	}
+
+	output := stderr.String()
+	if !strings.HasPrefix(output, "check panicked: assignment to entry in nil map\n") {
+		t.Errorf("expected stderr to start with the panic message got %q", output)
+	}
+	if exp := "resource_test.panickingCheck("; !strings.Contains(output, exp) {
+		t.Errorf("expected stack trace to contain %q got %q", exp, output)
+	}
+	for _, unexpected := range []string{"runtime/debug.Stack", "panic(", "resource.handleJSON"} {
+		if strings.Contains(output, unexpected) {
+			t.Errorf("expected stack trace not to contain %q got %q", unexpected, output)
+		}
+	}
+}
+
+func panickingCheck(context.Context, *log.Logger, example.Resource, example.Version) ([]example.Version, error) {
+	var m map[string]int
+	m["banana"]++
+	return nil, nil
+}
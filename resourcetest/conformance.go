@@ -0,0 +1,275 @@
+package resourcetest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+// Scenario is a request Conformance sends to a resource.
+type Scenario[ResourceParams, GetParams, PutParams, Version any] struct {
+	// Name is the subtest name. The default is the index of the scenario.
+	Name string
+
+	Source ResourceParams
+
+	// Version is the version check is called with. The zero value checks without a version
+	// (the first check of a resource). Get is called with Version, or when it is the zero value,
+	// with the last version returned by check.
+	Version Version
+
+	// VersionNotFound means Version no longer exists, so check does not need to return it.
+	VersionNotFound bool
+
+	GetParams GetParams
+
+	// Put enables calling put with PutParams. Put is not called by default because it usually has side effects.
+	Put       bool
+	PutParams PutParams
+}
+
+// Conformance runs each scenario through resource.RunWithCustomization as a subtest and reports
+// responses that violate the Concourse resource protocol (https://concourse-ci.org/implementing-resource-types.html):
+//
+//   - check must write a JSON array (not null) of versions without duplicates
+//   - check called with a version must start with that version (unless Scenario.VersionNotFound is set)
+//   - versions must be non-empty JSON objects with string values
+//   - in must write the requested version
+//   - out must write a non-empty version that in can fetch
+//   - metadata fields must have a name
+//
+// Get or Put may be nil to skip calling in or out.
+func Conformance[ResourceParams, GetParams, PutParams, Version any](
+	t *testing.T,
+	get resource.Get[ResourceParams, GetParams, Version],
+	put resource.Put[ResourceParams, PutParams, Version],
+	check resource.Check[ResourceParams, Version],
+	scenarios []Scenario[ResourceParams, GetParams, PutParams, Version],
+	opts ...Option,
+) {
+	t.Helper()
+	o := newOptions(opts)
+	cmd := resource.RunWithCustomization(o.customization, get, put, check)
+	for i, scenario := range scenarios {
+		name := scenario.Name
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		t.Run(name, func(t *testing.T) {
+			conform(t, cmd, get != nil, put != nil, scenario, opts)
+		})
+	}
+}
+
+func conform[ResourceParams, GetParams, PutParams, Version any](t testing.TB, cmd Command, hasGet, hasPut bool, scenario Scenario[ResourceParams, GetParams, PutParams, Version], opts []Option) {
+	t.Helper()
+	requested, err := versionObject(scenario.Version)
+	if err != nil {
+		t.Fatalf("failed to encode scenario version: %s", err)
+	}
+	checkRequest := struct {
+		Source  ResourceParams `json:"source"`
+		Version *Version       `json:"version"`
+	}{Source: scenario.Source}
+	if len(requested) > 0 {
+		checkRequest.Version = &scenario.Version
+	}
+	inv := Invoke(t, cmd, "check", checkRequest, opts...)
+	if inv.Err != nil {
+		t.Fatalf("check: unexpected error: %s\nstderr:\n%s", inv.Err, inv.Stderr)
+	}
+	versions := conformCheck[Version](t, inv.Stdout, requested, scenario.VersionNotFound)
+
+	getVersion := scenario.Version
+	if len(requested) == 0 && len(versions) > 0 {
+		getVersion = versions[len(versions)-1]
+	}
+	if hasGet && (len(requested) > 0 || len(versions) > 0) {
+		conformGet(t, cmd, scenario.Source, scenario.GetParams, getVersion, opts)
+	}
+
+	if hasPut && scenario.Put {
+		inv := Invoke(t, cmd, "out", struct {
+			Source ResourceParams `json:"source"`
+			Params PutParams      `json:"params"`
+		}{scenario.Source, scenario.PutParams}, opts...)
+		if inv.Err != nil {
+			t.Fatalf("out: unexpected error: %s\nstderr:\n%s", inv.Err, inv.Stderr)
+		}
+		var res struct {
+			Version  json.RawMessage          `json:"version"`
+			Metadata []resource.MetadataField `json:"metadata"`
+		}
+		if err := json.Unmarshal([]byte(inv.Stdout), &res); err != nil {
+			t.Fatalf("out: response %q is not a JSON object: %s", inv.Stdout, err)
+		}
+		conformMetadata(t, "out", res.Metadata)
+		if !conformVersion(t, "out: version", res.Version) {
+			return
+		}
+		var version Version
+		if err := json.Unmarshal(res.Version, &version); err != nil {
+			t.Fatalf("out: failed to decode version %s: %s", res.Version, err)
+		}
+		if hasGet {
+			conformGet(t, cmd, scenario.Source, scenario.GetParams, version, opts)
+		}
+	}
+}
+
+// conformCheck reports violations in the check response and returns the decoded versions.
+func conformCheck[Version any](t testing.TB, stdout string, requested map[string]any, versionNotFound bool) []Version {
+	t.Helper()
+	var raw []json.RawMessage
+	if err := json.Unmarshal([]byte(stdout), &raw); err != nil {
+		t.Errorf("check: response %q is not a JSON array: %s", stdout, err)
+		return nil
+	}
+	if raw == nil {
+		t.Errorf("check: response is null; it must be a JSON array (use [] when there are no versions)")
+		return nil
+	}
+	seen := make(map[string]int)
+	for i, r := range raw {
+		name := fmt.Sprintf("check: version [%d]", i)
+		if !conformVersion(t, name, r) {
+			continue
+		}
+		fields, _ := versionObject(r)
+		key := string(mustMarshal(fields))
+		if j, ok := seen[key]; ok {
+			t.Errorf("%s %s duplicates version [%d]", name, compactJSON(r), j)
+		}
+		seen[key] = i
+	}
+	if len(requested) > 0 {
+		requestedJSON, _ := json.Marshal(requested)
+		i, ok := seen[string(requestedJSON)]
+		switch {
+		case ok && i != 0:
+			t.Errorf("check: requested version %s is at index %d; versions must be in chronological order starting with the requested version", requestedJSON, i)
+		case !ok && !versionNotFound:
+			t.Errorf("check: response does not include the requested version %s; set Scenario.VersionNotFound when it no longer exists", requestedJSON)
+		}
+	}
+	var versions []Version
+	if err := json.Unmarshal([]byte(stdout), &versions); err != nil {
+		t.Errorf("check: failed to decode versions: %s", err)
+	}
+	return versions
+}
+
+func conformGet[ResourceParams, GetParams, Version any](t testing.TB, cmd Command, source ResourceParams, params GetParams, version Version, opts []Option) {
+	t.Helper()
+	requested, err := versionObject(version)
+	if err != nil {
+		t.Fatalf("in: failed to encode version: %s", err)
+	}
+	inv := Invoke(t, cmd, "in", struct {
+		Source  ResourceParams `json:"source"`
+		Params  GetParams      `json:"params"`
+		Version Version        `json:"version"`
+	}{source, params, version}, opts...)
+	if inv.Err != nil {
+		t.Fatalf("in: unexpected error: %s\nstderr:\n%s", inv.Err, inv.Stderr)
+	}
+	var res struct {
+		Version  json.RawMessage          `json:"version"`
+		Metadata []resource.MetadataField `json:"metadata"`
+	}
+	if err := json.Unmarshal([]byte(inv.Stdout), &res); err != nil {
+		t.Errorf("in: response %q is not a JSON object: %s", inv.Stdout, err)
+		return
+	}
+	conformMetadata(t, "in", res.Metadata)
+	if !conformVersion(t, "in: version", res.Version) {
+		return
+	}
+	var got map[string]any
+	_ = json.Unmarshal(res.Version, &got)
+	for key, value := range requested {
+		if got[key] != value {
+			t.Errorf("in: version field %q is %s but %s was requested; in must fetch the requested version", key, mustMarshal(got[key]), mustMarshal(value))
+		}
+	}
+}
+
+// conformVersion reports whether raw is a non-empty JSON object with string values.
+func conformVersion(t testing.TB, name string, raw json.RawMessage) bool {
+	t.Helper()
+	if len(raw) == 0 || string(raw) == "null" {
+		t.Errorf("%s is missing", name)
+		return false
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		t.Errorf("%s %s is not a JSON object", name, raw)
+		return false
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	ok := true
+	empty := true
+	for _, key := range keys {
+		value := fields[key]
+		s, isString := value.(string)
+		if !isString {
+			t.Errorf("%s field %q is %s; version values must be strings", name, key, mustMarshal(value))
+			ok = false
+		}
+		if s != "" {
+			empty = false
+		}
+	}
+	if empty {
+		t.Errorf("%s %s is empty", name, raw)
+		ok = false
+	}
+	return ok
+}
+
+func conformMetadata(t testing.TB, command string, metadata []resource.MetadataField) {
+	t.Helper()
+	for i, field := range metadata {
+		if field.Key == "" {
+			t.Errorf("%s: metadata [%d] has no key", command, i)
+		}
+	}
+}
+
+// versionObject returns the JSON object encoding of v without empty fields.
+func versionObject(v any) (map[string]any, error) {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(buf, &fields); err != nil {
+		return nil, err
+	}
+	for key, value := range fields {
+		if value == nil || value == "" {
+			delete(fields, key)
+		}
+	}
+	return fields, nil
+}
+
+func compactJSON(raw []byte) string {
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	return string(mustMarshal(v))
+}
+
+func mustMarshal(v any) []byte {
+	buf, _ := json.Marshal(v)
+	return buf
+}
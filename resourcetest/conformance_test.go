@@ -0,0 +1,124 @@
+package resourcetest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+type exampleScenario = Scenario[example.Resource, example.GetParams, example.PutParams, example.Version]
+
+func conformingCheck(_ context.Context, _ *log.Logger, _ example.Resource, version example.Version) ([]example.Version, error) {
+	switch version.Ref {
+	case "":
+		return []example.Version{{Ref: "c"}}, nil
+	case "gone":
+		return []example.Version{{Ref: "c"}}, nil
+	default:
+		return []example.Version{version, {Ref: "c"}}, nil
+	}
+}
+
+func conformingGet(context.Context, *log.Logger, example.Resource, example.GetParams, example.Version, string) ([]resource.MetadataField, error) {
+	return []resource.MetadataField{{Key: "author", Value: "someone"}}, nil
+}
+
+func conformingPut(context.Context, *log.Logger, example.Resource, example.PutParams, string) (example.Version, []resource.MetadataField, error) {
+	return example.Version{Ref: "d"}, nil, nil
+}
+
+func TestConformance(t *testing.T) {
+	Conformance(t, conformingGet, conformingPut, conformingCheck, []exampleScenario{
+		{Name: "first check"},
+		{Name: "from version", Version: example.Version{Ref: "a"}},
+		{Name: "version not found", Version: example.Version{Ref: "gone"}, VersionNotFound: true},
+		{Name: "put", Version: example.Version{Ref: "a"}, Put: true},
+	})
+}
+
+type violations struct {
+	testing.TB
+	errors []string
+}
+
+func (v *violations) Helper() {}
+
+func (v *violations) Errorf(format string, args ...any) {
+	v.errors = append(v.errors, fmt.Sprintf(format, args...))
+}
+
+func TestConformance_violations(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		get      resource.Get[example.Resource, example.GetParams, example.Version]
+		put      resource.Put[example.Resource, example.PutParams, example.Version]
+		check    resource.Check[example.Resource, example.Version]
+		scenario exampleScenario
+		expected []string
+	}{
+		{
+			name: "check omits the requested version",
+			check: func(context.Context, *log.Logger, example.Resource, example.Version) ([]example.Version, error) {
+				return []example.Version{{Ref: "c"}}, nil
+			},
+			scenario: exampleScenario{Version: example.Version{Ref: "a"}},
+			expected: []string{`check: response does not include the requested version {"ref":"a"}; set Scenario.VersionNotFound when it no longer exists`},
+		},
+		{
+			name: "check order and duplicates",
+			check: func(context.Context, *log.Logger, example.Resource, example.Version) ([]example.Version, error) {
+				return []example.Version{{Ref: "c"}, {Ref: "a"}, {Ref: "c"}, {}}, nil
+			},
+			scenario: exampleScenario{Version: example.Version{Ref: "a"}},
+			expected: []string{
+				`check: version [2] {"ref":"c"} duplicates version [0]`,
+				`check: version [3] {"ref":""} is empty`,
+				`check: requested version {"ref":"a"} is at index 1; versions must be in chronological order starting with the requested version`,
+			},
+		},
+		{
+			name:  "in writes metadata without a key",
+			check: conformingCheck,
+			get: func(context.Context, *log.Logger, example.Resource, example.GetParams, example.Version, string) ([]resource.MetadataField, error) {
+				return []resource.MetadataField{{Key: "a"}, {Value: "b"}}, nil
+			},
+			scenario: exampleScenario{Version: example.Version{Ref: "a"}},
+			expected: []string{"in: metadata [1] has no key"},
+		},
+		{
+			name:  "put writes an empty version",
+			check: conformingCheck,
+			put: func(context.Context, *log.Logger, example.Resource, example.PutParams, string) (example.Version, []resource.MetadataField, error) {
+				return example.Version{}, []resource.MetadataField{{Value: "no key"}}, nil
+			},
+			scenario: exampleScenario{Version: example.Version{Ref: "a"}, Put: true},
+			expected: []string{
+				"out: metadata [0] has no key",
+				`out: version {"ref":""} is empty`,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &violations{TB: t}
+			cmd := resource.RunWithCustomization(resource.Customization{}, tt.get, tt.put, tt.check)
+			conform(v, cmd, tt.get != nil, tt.put != nil, tt.scenario, nil)
+			if strings.Join(v.errors, "\n") != strings.Join(tt.expected, "\n") {
+				t.Errorf("unexpected violations (- expected, + got):\n%s", diff(strings.Join(tt.expected, "\n"), strings.Join(v.errors, "\n")))
+			}
+		})
+	}
+}
+
+func TestConformance_nullCheckResponse(t *testing.T) {
+	// the dispatcher writes [] for a nil slice, so null only comes from other implementations
+	v := &violations{TB: t}
+	conformCheck[example.Version](v, "null\n", nil, false)
+	if exp := "check: response is null; it must be a JSON array (use [] when there are no versions)"; len(v.errors) != 1 || v.errors[0] != exp {
+		t.Errorf("expected %q got %q", exp, v.errors)
+	}
+}
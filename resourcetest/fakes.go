@@ -0,0 +1,218 @@
+package resourcetest
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/crhntr/resource"
+)
+
+// FakeGet records calls to its Spy method, which has the signature of resource.Get.
+// Pass fake.Spy to resource.Run. The zero value returns zero values.
+type FakeGet[ResourceParams, GetParams, Version any] struct {
+	fake[getCall[ResourceParams, GetParams, Version], getResult]
+}
+
+type getCall[ResourceParams, GetParams, Version any] struct {
+	ctx     context.Context
+	logger  *log.Logger
+	source  ResourceParams
+	params  GetParams
+	version Version
+	dir     string
+}
+
+type getResult struct {
+	metadata []resource.MetadataField
+	err      error
+}
+
+var _ resource.Get[struct{}, struct{}, struct{}] = new(FakeGet[struct{}, struct{}, struct{}]).Spy
+
+func (f *FakeGet[ResourceParams, GetParams, Version]) Spy(ctx context.Context, logger *log.Logger, source ResourceParams, params GetParams, version Version, dir string) ([]resource.MetadataField, error) {
+	result, stub := f.record(getCall[ResourceParams, GetParams, Version]{ctx, logger, source, params, version, dir})
+	if stub, ok := stub.(resource.Get[ResourceParams, GetParams, Version]); ok && stub != nil {
+		return stub(ctx, logger, source, params, version, dir)
+	}
+	return result.metadata, result.err
+}
+
+// Calls sets a function to call instead of returning the values set by Returns and ReturnsOnCall.
+func (f *FakeGet[ResourceParams, GetParams, Version]) Calls(stub resource.Get[ResourceParams, GetParams, Version]) {
+	f.setStub(stub)
+}
+
+// ArgsForCall returns the arguments of the i-th (zero based) call to Spy.
+func (f *FakeGet[ResourceParams, GetParams, Version]) ArgsForCall(i int) (context.Context, *log.Logger, ResourceParams, GetParams, Version, string) {
+	c := f.call(i)
+	return c.ctx, c.logger, c.source, c.params, c.version, c.dir
+}
+
+// Returns sets the values Spy returns and removes any function set by Calls.
+func (f *FakeGet[ResourceParams, GetParams, Version]) Returns(metadata []resource.MetadataField, err error) {
+	f.setReturns(getResult{metadata, err})
+}
+
+// ReturnsOnCall sets the values the i-th (zero based) call to Spy returns and removes any function set by Calls.
+func (f *FakeGet[ResourceParams, GetParams, Version]) ReturnsOnCall(i int, metadata []resource.MetadataField, err error) {
+	f.setReturnsOnCall(i, getResult{metadata, err})
+}
+
+// FakePut records calls to its Spy method, which has the signature of resource.Put.
+// Pass fake.Spy to resource.Run. The zero value returns zero values.
+type FakePut[ResourceParams, PutParams, Version any] struct {
+	fake[putCall[ResourceParams, PutParams], putResult[Version]]
+}
+
+type putCall[ResourceParams, PutParams any] struct {
+	ctx    context.Context
+	logger *log.Logger
+	source ResourceParams
+	params PutParams
+	dir    string
+}
+
+type putResult[Version any] struct {
+	version  Version
+	metadata []resource.MetadataField
+	err      error
+}
+
+var _ resource.Put[struct{}, struct{}, struct{}] = new(FakePut[struct{}, struct{}, struct{}]).Spy
+
+func (f *FakePut[ResourceParams, PutParams, Version]) Spy(ctx context.Context, logger *log.Logger, source ResourceParams, params PutParams, dir string) (Version, []resource.MetadataField, error) {
+	result, stub := f.record(putCall[ResourceParams, PutParams]{ctx, logger, source, params, dir})
+	if stub, ok := stub.(resource.Put[ResourceParams, PutParams, Version]); ok && stub != nil {
+		return stub(ctx, logger, source, params, dir)
+	}
+	return result.version, result.metadata, result.err
+}
+
+// Calls sets a function to call instead of returning the values set by Returns and ReturnsOnCall.
+func (f *FakePut[ResourceParams, PutParams, Version]) Calls(stub resource.Put[ResourceParams, PutParams, Version]) {
+	f.setStub(stub)
+}
+
+// ArgsForCall returns the arguments of the i-th (zero based) call to Spy.
+func (f *FakePut[ResourceParams, PutParams, Version]) ArgsForCall(i int) (context.Context, *log.Logger, ResourceParams, PutParams, string) {
+	c := f.call(i)
+	return c.ctx, c.logger, c.source, c.params, c.dir
+}
+
+// Returns sets the values Spy returns and removes any function set by Calls.
+func (f *FakePut[ResourceParams, PutParams, Version]) Returns(version Version, metadata []resource.MetadataField, err error) {
+	f.setReturns(putResult[Version]{version, metadata, err})
+}
+
+// ReturnsOnCall sets the values the i-th (zero based) call to Spy returns and removes any function set by Calls.
+func (f *FakePut[ResourceParams, PutParams, Version]) ReturnsOnCall(i int, version Version, metadata []resource.MetadataField, err error) {
+	f.setReturnsOnCall(i, putResult[Version]{version, metadata, err})
+}
+
+// FakeCheck records calls to its Spy method, which has the signature of resource.Check.
+// Pass fake.Spy to resource.Run. The zero value returns zero values.
+type FakeCheck[ResourceParams, Version any] struct {
+	fake[checkCall[ResourceParams, Version], checkResult[Version]]
+}
+
+type checkCall[ResourceParams, Version any] struct {
+	ctx     context.Context
+	logger  *log.Logger
+	source  ResourceParams
+	version Version
+}
+
+type checkResult[Version any] struct {
+	versions []Version
+	err      error
+}
+
+var _ resource.Check[struct{}, struct{}] = new(FakeCheck[struct{}, struct{}]).Spy
+
+func (f *FakeCheck[ResourceParams, Version]) Spy(ctx context.Context, logger *log.Logger, source ResourceParams, version Version) ([]Version, error) {
+	result, stub := f.record(checkCall[ResourceParams, Version]{ctx, logger, source, version})
+	if stub, ok := stub.(resource.Check[ResourceParams, Version]); ok && stub != nil {
+		return stub(ctx, logger, source, version)
+	}
+	return result.versions, result.err
+}
+
+// Calls sets a function to call instead of returning the values set by Returns and ReturnsOnCall.
+func (f *FakeCheck[ResourceParams, Version]) Calls(stub resource.Check[ResourceParams, Version]) {
+	f.setStub(stub)
+}
+
+// ArgsForCall returns the arguments of the i-th (zero based) call to Spy.
+func (f *FakeCheck[ResourceParams, Version]) ArgsForCall(i int) (context.Context, *log.Logger, ResourceParams, Version) {
+	c := f.call(i)
+	return c.ctx, c.logger, c.source, c.version
+}
+
+// Returns sets the values Spy returns and removes any function set by Calls.
+func (f *FakeCheck[ResourceParams, Version]) Returns(versions []Version, err error) {
+	f.setReturns(checkResult[Version]{versions, err})
+}
+
+// ReturnsOnCall sets the values the i-th (zero based) call to Spy returns and removes any function set by Calls.
+func (f *FakeCheck[ResourceParams, Version]) ReturnsOnCall(i int, versions []Version, err error) {
+	f.setReturnsOnCall(i, checkResult[Version]{versions, err})
+}
+
+// fake holds the state shared by FakeGet, FakePut, and FakeCheck.
+// It is safe for concurrent use.
+type fake[Call, Result any] struct {
+	mu            sync.RWMutex
+	calls         []Call
+	stub          any
+	returns       Result
+	returnsOnCall map[int]Result
+}
+
+// record appends c and returns the result for the call or the function set by Calls.
+func (f *fake[Call, Result]) record(c Call) (Result, any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	result, ok := f.returnsOnCall[len(f.calls)]
+	if !ok {
+		result = f.returns
+	}
+	f.calls = append(f.calls, c)
+	return result, f.stub
+}
+
+// CallCount returns the number of calls to Spy.
+func (f *fake[Call, Result]) CallCount() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.calls)
+}
+
+func (f *fake[Call, Result]) call(i int) Call {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.calls[i]
+}
+
+func (f *fake[Call, Result]) setStub(stub any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stub = stub
+}
+
+func (f *fake[Call, Result]) setReturns(r Result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stub = nil
+	f.returns = r
+}
+
+func (f *fake[Call, Result]) setReturnsOnCall(i int, r Result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stub = nil
+	if f.returnsOnCall == nil {
+		f.returnsOnCall = make(map[int]Result)
+	}
+	f.returnsOnCall[i] = r
+}
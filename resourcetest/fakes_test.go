@@ -0,0 +1,65 @@
+package resourcetest_test
+
+import (
+	"context"
+	"errors"
+	"log"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+	"github.com/crhntr/resource/resourcetest"
+)
+
+func TestFakeCheck(t *testing.T) {
+	check := new(resourcetest.FakeCheck[example.Resource, example.Version])
+	check.Returns([]example.Version{{Ref: "a"}}, nil)
+	check.ReturnsOnCall(1, nil, errors.New("banana"))
+
+	ctx := context.Background()
+	if versions, err := check.Spy(ctx, nil, example.Resource{URI: "first"}, example.Version{}); err != nil || len(versions) != 1 {
+		t.Errorf("unexpected result %v %v", versions, err)
+	}
+	if _, err := check.Spy(ctx, nil, example.Resource{URI: "second"}, example.Version{}); err == nil || err.Error() != "banana" {
+		t.Errorf("expected the error set by ReturnsOnCall got %v", err)
+	}
+	if versions, err := check.Spy(ctx, nil, example.Resource{URI: "third"}, example.Version{Ref: "c"}); err != nil || len(versions) != 1 {
+		t.Errorf("unexpected result %v %v", versions, err)
+	}
+
+	if got := check.CallCount(); got != 3 {
+		t.Errorf("expected 3 calls got %d", got)
+	}
+	if _, _, source, version := check.ArgsForCall(2); source.URI != "third" || version.Ref != "c" {
+		t.Errorf("unexpected arguments %#v %#v", source, version)
+	}
+}
+
+func TestFakeGet_Calls(t *testing.T) {
+	get := new(resourcetest.FakeGet[example.Resource, example.GetParams, example.Version])
+	get.Calls(func(_ context.Context, _ *log.Logger, _ example.Resource, _ example.GetParams, version example.Version, _ string) ([]resource.MetadataField, error) {
+		return []resource.MetadataField{{Key: "ref", Value: version.Ref}}, nil
+	})
+
+	res := resourcetest.Get(t, get.Spy, example.Resource{}, example.GetParams{IncludeZip: true}, example.Version{Ref: "a"})
+
+	resourcetest.Equal(t, []resource.MetadataField{{Key: "ref", Value: "a"}}, res.Metadata)
+	if _, _, _, params, _, dir := get.ArgsForCall(0); !params.IncludeZip || dir != res.Dir {
+		t.Errorf("unexpected arguments %#v %q", params, dir)
+	}
+}
+
+func TestFakePut(t *testing.T) {
+	put := new(resourcetest.FakePut[example.Resource, example.PutParams, example.Version])
+
+	res := resourcetest.Put(t, put.Spy, example.Resource{}, example.PutParams{})
+	resourcetest.Equal(t, example.Version{}, res.Version)
+
+	put.Returns(example.Version{Ref: "b"}, nil, nil)
+	res = resourcetest.Put(t, put.Spy, example.Resource{}, example.PutParams{EnsureChecksum: true})
+	resourcetest.Equal(t, example.Version{Ref: "b"}, res.Version)
+
+	if _, _, _, params, _ := put.ArgsForCall(1); !params.EnsureChecksum {
+		t.Errorf("unexpected params %#v", params)
+	}
+}
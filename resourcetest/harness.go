@@ -0,0 +1,206 @@
+package resourcetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+// BuildEnvironment is the environment Get and Put set before calling the handler.
+// Concourse sets these variables for in and out but not for check, so Check unsets them.
+var BuildEnvironment = map[string]string{
+	"BUILD_ID":            "1",
+	"BUILD_NAME":          "1",
+	"BUILD_JOB_NAME":      "job",
+	"BUILD_PIPELINE_NAME": "pipeline",
+	"BUILD_TEAM_NAME":     "main",
+	"BUILD_CREATED_BY":    "test",
+	"ATC_EXTERNAL_URL":    "http://localhost:8080",
+}
+
+// Option configures Check, Get, Put, and Invoke.
+type Option func(*options)
+
+type options struct {
+	customization resource.Customization
+	env           map[string]string
+	dir           string
+}
+
+// WithCustomization sets the Customization passed to resource.RunWithCustomization.
+// The default is the zero value.
+func WithCustomization(customization resource.Customization) Option {
+	return func(o *options) { o.customization = customization }
+}
+
+// WithEnv sets an environment variable (for example overriding one in BuildEnvironment) for the call.
+func WithEnv(key, value string) Option {
+	return func(o *options) { o.env[key] = value }
+}
+
+// WithDir sets the directory argument of in and out. The default is a new temporary directory.
+func WithDir(dir string) Option {
+	return func(o *options) { o.dir = dir }
+}
+
+func newOptions(opts []Option) options {
+	o := options{env: make(map[string]string)}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// Invocation is the result of Invoke.
+type Invocation struct {
+	Stdout, Stderr string
+	Err            error
+
+	// Dir is the directory argument passed to in or out.
+	Dir string
+}
+
+// Invoke marshals request to JSON and runs cmd as command ("check", "in", or "out") with it on stdin.
+// Use it to test failures; Check, Get, and Put fail the test when the handler returns an error.
+func Invoke(t testing.TB, cmd Command, command string, request any, opts ...Option) Invocation {
+	t.Helper()
+	o := newOptions(opts)
+	if command == "check" {
+		for key := range BuildEnvironment {
+			if _, ok := o.env[key]; ok {
+				continue
+			}
+			t.Setenv(key, "")
+			_ = os.Unsetenv(key)
+		}
+	} else {
+		for key, value := range BuildEnvironment {
+			if _, ok := o.env[key]; !ok {
+				t.Setenv(key, value)
+			}
+		}
+	}
+	for key, value := range o.env {
+		t.Setenv(key, value)
+	}
+
+	stdin, err := json.Marshal(request)
+	if err != nil {
+		t.Fatalf("failed to encode request: %s", err)
+	}
+	args := []string{"/opt/resource/" + command}
+	if command != "check" {
+		if o.dir == "" {
+			o.dir = t.TempDir()
+		}
+		args = append(args, o.dir)
+	}
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	err = cmd(stdout, stderr, bytes.NewReader(stdin), args)
+	return Invocation{Stdout: stdout.String(), Stderr: stderr.String(), Err: err, Dir: o.dir}
+}
+
+// Response is the decoded response of Get or Put.
+type Response[Version any] struct {
+	Version  Version                  `json:"version"`
+	Metadata []resource.MetadataField `json:"metadata,omitempty"`
+
+	// Dir is the directory argument passed to in or out.
+	Dir string `json:"-"`
+}
+
+// Check calls check through resource.RunWithCustomization and returns the decoded versions.
+// The test fails when check returns an error or the response can not be decoded.
+func Check[ResourceParams, Version any](t testing.TB, check resource.Check[ResourceParams, Version], source ResourceParams, version Version, opts ...Option) []Version {
+	t.Helper()
+	o := newOptions(opts)
+	cmd := resource.RunWithCustomization[ResourceParams, struct{}, struct{}, Version](o.customization, nil, nil, check)
+	inv := Invoke(t, cmd, "check", struct {
+		Source  ResourceParams `json:"source"`
+		Version Version        `json:"version"`
+	}{source, version}, opts...)
+	var versions []Version
+	decodeResponse(t, inv, &versions)
+	return versions
+}
+
+// Get calls get through resource.RunWithCustomization with a new temporary directory
+// and the BuildEnvironment, and returns the decoded response.
+// The test fails when get returns an error or the response can not be decoded.
+func Get[ResourceParams, GetParams, Version any](t testing.TB, get resource.Get[ResourceParams, GetParams, Version], source ResourceParams, params GetParams, version Version, opts ...Option) Response[Version] {
+	t.Helper()
+	o := newOptions(opts)
+	cmd := resource.RunWithCustomization[ResourceParams, GetParams, struct{}, Version](o.customization, get, nil, nil)
+	inv := Invoke(t, cmd, "in", struct {
+		Source  ResourceParams `json:"source"`
+		Params  GetParams      `json:"params"`
+		Version Version        `json:"version"`
+	}{source, params, version}, opts...)
+	var res Response[Version]
+	decodeResponse(t, inv, &res)
+	res.Dir = inv.Dir
+	return res
+}
+
+// GetVersion is like Get but calls a resource.GetVersion through resource.RunGetVersionWithCustomization.
+func GetVersion[ResourceParams, GetParams, Version any](t testing.TB, get resource.GetVersion[ResourceParams, GetParams, Version], source ResourceParams, params GetParams, version Version, opts ...Option) Response[Version] {
+	t.Helper()
+	o := newOptions(opts)
+	cmd := resource.RunGetVersionWithCustomization[ResourceParams, GetParams, struct{}, Version](o.customization, get, nil, nil)
+	inv := Invoke(t, cmd, "in", struct {
+		Source  ResourceParams `json:"source"`
+		Params  GetParams      `json:"params"`
+		Version Version        `json:"version"`
+	}{source, params, version}, opts...)
+	var res Response[Version]
+	decodeResponse(t, inv, &res)
+	res.Dir = inv.Dir
+	return res
+}
+
+// Put calls put through resource.RunWithCustomization with a new temporary directory
+// and the BuildEnvironment, and returns the decoded response.
+// The test fails when put returns an error or the response can not be decoded.
+func Put[ResourceParams, PutParams, Version any](t testing.TB, put resource.Put[ResourceParams, PutParams, Version], source ResourceParams, params PutParams, opts ...Option) Response[Version] {
+	t.Helper()
+	o := newOptions(opts)
+	cmd := resource.RunWithCustomization[ResourceParams, struct{}, PutParams, Version](o.customization, nil, put, nil)
+	inv := Invoke(t, cmd, "out", struct {
+		Source ResourceParams `json:"source"`
+		Params PutParams      `json:"params"`
+	}{source, params}, opts...)
+	var res Response[Version]
+	decodeResponse(t, inv, &res)
+	res.Dir = inv.Dir
+	return res
+}
+
+func decodeResponse(t testing.TB, inv Invocation, res any) {
+	t.Helper()
+	if inv.Err != nil {
+		t.Fatalf("unexpected error: %s\nstderr:\n%s", inv.Err, inv.Stderr)
+	}
+	if err := json.Unmarshal([]byte(inv.Stdout), res); err != nil {
+		t.Fatalf("failed to decode response %q: %s\nstderr:\n%s", inv.Stdout, err, inv.Stderr)
+	}
+}
+
+// Equal fails the test with a line diff of the JSON encodings when exp and got encode differently.
+// Use it to compare versions, responses, and metadata.
+func Equal(t testing.TB, exp, got any) {
+	t.Helper()
+	expJSON, err := json.Marshal(exp)
+	if err != nil {
+		t.Fatalf("failed to encode expected value: %s", err)
+	}
+	gotJSON, err := json.Marshal(got)
+	if err != nil {
+		t.Fatalf("failed to encode value: %s", err)
+	}
+	if !bytes.Equal(expJSON, gotJSON) {
+		t.Errorf("values differ (- expected, + got):\n%s", diff(string(expJSON), string(gotJSON)))
+	}
+}
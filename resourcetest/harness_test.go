@@ -0,0 +1,118 @@
+package resourcetest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+	"github.com/crhntr/resource/resourcetest"
+)
+
+func TestCheck(t *testing.T) {
+	t.Setenv("BUILD_ID", "99")
+
+	versions := resourcetest.Check(t, func(_ context.Context, _ *log.Logger, source example.Resource, version example.Version) ([]example.Version, error) {
+		if _, ok := (resource.Build{}).ID(); ok {
+			t.Errorf("expected BUILD_ID to be unset in check")
+		}
+		if source.Branch != "main" {
+			t.Errorf("unexpected source %#v", source)
+		}
+		return []example.Version{version, {Ref: "b"}}, nil
+	}, example.Resource{Branch: "main"}, example.Version{Ref: "a"})
+
+	resourcetest.Equal(t, []example.Version{{Ref: "a"}, {Ref: "b"}}, versions)
+}
+
+func TestGet(t *testing.T) {
+	res := resourcetest.Get(t, func(_ context.Context, _ *log.Logger, _ example.Resource, params example.GetParams, version example.Version, dir string) ([]resource.MetadataField, error) {
+		if !params.IncludeZip {
+			t.Errorf("expected params to be passed")
+		}
+		id, _ := resource.Build{}.ID()
+		team, _ := resource.Build{}.TeamName()
+		return []resource.MetadataField{{Key: "id", Value: id}, {Key: "team", Value: team}}, os.WriteFile(filepath.Join(dir, "ref"), []byte(version.Ref), 0o644)
+	}, example.Resource{}, example.GetParams{IncludeZip: true}, example.Version{Ref: "a"}, resourcetest.WithEnv("BUILD_TEAM_NAME", "blue"))
+
+	resourcetest.Equal(t, resourcetest.Response[example.Version]{
+		Version:  example.Version{Ref: "a"},
+		Metadata: []resource.MetadataField{{Key: "id", Value: "1"}, {Key: "team", Value: "blue"}},
+	}, res)
+	if buf, err := os.ReadFile(filepath.Join(res.Dir, "ref")); err != nil || string(buf) != "a" {
+		t.Errorf("expected the ref file to be written to the destination directory: %q %v", buf, err)
+	}
+}
+
+func TestPut(t *testing.T) {
+	dir := t.TempDir()
+	res := resourcetest.Put(t, func(_ context.Context, _ *log.Logger, _ example.Resource, _ example.PutParams, got string) (example.Version, []resource.MetadataField, error) {
+		if got != dir {
+			t.Errorf("expected directory %q got %q", dir, got)
+		}
+		return example.Version{Ref: "c"}, nil, nil
+	}, example.Resource{}, example.PutParams{}, resourcetest.WithDir(dir))
+
+	resourcetest.Equal(t, example.Version{Ref: "c"}, res.Version)
+}
+
+func TestInvoke(t *testing.T) {
+	cmd := resource.RunWithCustomization[example.Resource, example.GetParams, example.PutParams, example.Version](resource.Customization{}, nil, nil,
+		func(_ context.Context, logger *log.Logger, _ example.Resource, _ example.Version) ([]example.Version, error) {
+			logger.Println("fetching")
+			return nil, errors.New("banana")
+		})
+
+	inv := resourcetest.Invoke(t, cmd, "check", map[string]any{"source": map[string]any{}})
+
+	if inv.Err == nil || inv.Err.Error() != "banana" {
+		t.Errorf("unexpected error %v", inv.Err)
+	}
+	if inv.Stderr != "fetching\n" {
+		t.Errorf("unexpected stderr %q", inv.Stderr)
+	}
+}
+
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestEqual(t *testing.T) {
+	r := &recorder{TB: t}
+
+	resourcetest.Equal(r, example.Version{Ref: "a"}, example.Version{Ref: "a"})
+	if len(r.errors) != 0 {
+		t.Fatalf("unexpected errors %q", r.errors)
+	}
+
+	resourcetest.Equal(r, []example.Version{{Ref: "a"}, {Ref: "b"}}, []example.Version{{Ref: "a"}, {Ref: "c"}})
+	if len(r.errors) != 1 {
+		t.Fatalf("expected one error got %q", r.errors)
+	}
+	for _, line := range []string{`-     "ref": "b"`, `+     "ref": "c"`, `      "ref": "a"`} {
+		if !strings.Contains(r.errors[0], line) {
+			t.Errorf("expected diff line %q in:\n%s", line, r.errors[0])
+		}
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	res := resourcetest.GetVersion(t, func(_ context.Context, _ *log.Logger, _ example.Resource, _ example.GetParams, version example.Version, _ string) (example.Version, []resource.MetadataField, error) {
+		return example.Version{Ref: version.Ref + "123"}, nil, nil
+	}, example.Resource{}, example.GetParams{}, example.Version{Ref: "abc"})
+
+	resourcetest.Equal(t, example.Version{Ref: "abc123"}, res.Version)
+}
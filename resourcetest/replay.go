@@ -0,0 +1,73 @@
+// Package resourcetest helps test Concourse resources built with github.com/crhntr/resource.
+package resourcetest
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+// Command is the type of the function returned by resource.Run and its variants.
+type Command = func(stdout, stderr io.Writer, stdin io.Reader, args []string) error
+
+// Replay runs each capture in the capture file at path (see resource.Capture) through cmd as a subtest.
+// The directory argument of in and out is replaced with a new temporary directory and
+// the captured BUILD_* variables are set in the environment.
+// The subtest fails when stdout or the exit status differs from the capture.
+// Captured stdout has secret values redacted, so the replay is also recorded (to a capture file in a temporary
+// directory) and its redacted stdout is compared.
+func Replay(t *testing.T, cmd Command, path string) {
+	t.Helper()
+	captures, err := readCaptureFile(path)
+	if err != nil {
+		t.Fatalf("failed to read captures from %s: %s", path, err)
+	}
+	for i, c := range captures {
+		t.Run(fmt.Sprintf("%d %s", i, c.Command), func(t *testing.T) {
+			t.Helper()
+			for key, value := range c.Env {
+				t.Setenv(key, value)
+			}
+			args := append([]string(nil), c.Args...)
+			if (c.Command == "in" || c.Command == "out") && len(args) > 1 {
+				args[len(args)-1] = t.TempDir()
+			}
+
+			replayPath := filepath.Join(t.TempDir(), "captures.jsonl")
+			t.Setenv(resource.CaptureEnvironmentVariable, replayPath)
+
+			stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+			err := cmd(stdout, stderr, strings.NewReader(c.Stdin), args)
+			replayed := stdout.String()
+			if captures, readErr := readCaptureFile(replayPath); readErr == nil && len(captures) == 1 {
+				replayed = captures[0].Stdout
+			}
+
+			exitStatus := 0
+			if err != nil {
+				exitStatus = 1
+			}
+			if exitStatus != c.ExitStatus {
+				t.Errorf("expected exit status %d got %d (error: %v)\nstderr:\n%s", c.ExitStatus, exitStatus, err, stderr)
+			}
+			if replayed != c.Stdout {
+				t.Errorf("stdout differs from capture (- captured, + replayed):\n%s", diff(c.Stdout, replayed))
+			}
+		})
+	}
+}
+
+func readCaptureFile(path string) ([]resource.Capture, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return resource.ReadCaptures(f)
+}
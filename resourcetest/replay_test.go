@@ -0,0 +1,66 @@
+package resourcetest_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+	"github.com/crhntr/resource/resourcetest"
+)
+
+func TestReplay(t *testing.T) {
+	var dirs []string
+	get := func(_ context.Context, _ *log.Logger, _ example.Resource, _ example.GetParams, _ example.Version, dir string) ([]resource.MetadataField, error) {
+		dirs = append(dirs, dir)
+		id, _ := resource.Build{}.ID()
+		return []resource.MetadataField{{Key: "build", Value: id}}, nil
+	}
+	put := func(context.Context, *log.Logger, example.Resource, example.PutParams, string) (example.Version, []resource.MetadataField, error) {
+		return example.Version{Ref: "abc"}, nil, nil
+	}
+	check := func(context.Context, *log.Logger, example.Resource, example.Version) ([]example.Version, error) {
+		return []example.Version{{Ref: "abc"}}, nil
+	}
+
+	capturePath := filepath.Join(t.TempDir(), "captures.jsonl")
+	record := resource.RunWithCustomization(resource.Customization{CaptureFile: capturePath}, get, put, check)
+	t.Setenv("BUILD_ID", "7")
+	if err := record(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"ref": "abc"}}`), []string{"check"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := record(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"ref": "abc"}}`), []string{"in", "/tmp/build/get"}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BUILD_ID", "")
+
+	resourcetest.Replay(t, resource.RunWithCustomization(resource.Customization{}, get, put, check), capturePath)
+
+	if len(dirs) != 2 || dirs[0] != "/tmp/build/get" || dirs[1] == "/tmp/build/get" {
+		t.Errorf("expected replay to use a temporary directory got %q", dirs)
+	}
+}
+
+type replaySecretSource struct {
+	Token resource.Secret `json:"token"`
+}
+
+func TestReplay_secrets(t *testing.T) {
+	get := func(_ context.Context, _ *log.Logger, source replaySecretSource, _ struct{}, _ example.Version, _ string) ([]resource.MetadataField, error) {
+		return []resource.MetadataField{{Key: "token", Value: string(source.Token)}}, nil
+	}
+	cmd := resource.RunWithCustomization[replaySecretSource, struct{}, struct{}, example.Version](resource.Customization{}, get, nil, nil)
+
+	capturePath := filepath.Join(t.TempDir(), "captures.jsonl")
+	t.Setenv(resource.CaptureEnvironmentVariable, capturePath)
+	if err := cmd(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(`{"source": {"token": "t0k3n"}, "version": {"ref": "abc"}}`), []string{"in", t.TempDir()}); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(resource.CaptureEnvironmentVariable, "")
+
+	resourcetest.Replay(t, cmd, capturePath)
+}
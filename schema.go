@@ -0,0 +1,243 @@
+package resource
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const commandSchema = "schema"
+
+// JSONSchema is a subset of a JSON Schema (draft 2020-12) document.
+// It is generated from Go types by Schema.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Enum                 []any                  `json:"enum,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties any                    `json:"additionalProperties,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+// Schema returns a JSON Schema document with definitions for "source", "get_params", "put_params", and "version"
+// derived from the json struct tags on the type parameters. It also reads the following struct tags:
+//
+//	description:"The git URI"  sets the property description
+//	enum:"main,develop"        lists the allowed values
+//	validate:"required"        adds the property to the required list
+//	default:"main"             sets the default (see Defaulter for how the value is parsed)
+//
+// When customization.DisallowUnknownFields is set, objects do not allow additional properties.
+//
+// The function returned by Run writes this document to stdout when called with "schema" as the first argument.
+// "schema source" (or get_params, put_params, version) writes only that definition.
+func Schema[ResourceParams, GetParams, PutParams, Version any](customization Customization) *JSONSchema {
+	return &JSONSchema{
+		Schema: "https://json-schema.org/draft/2020-12/schema",
+		Defs: map[string]*JSONSchema{
+			"source":     typeSchema(reflect.TypeOf((*ResourceParams)(nil)).Elem(), customization, nil),
+			"get_params": typeSchema(reflect.TypeOf((*GetParams)(nil)).Elem(), customization, nil),
+			"put_params": typeSchema(reflect.TypeOf((*PutParams)(nil)).Elem(), customization, nil),
+			"version":    typeSchema(reflect.TypeOf((*Version)(nil)).Elem(), customization, nil),
+		},
+	}
+}
+
+func writeSchema(stdout io.Writer, schema *JSONSchema, args []string) error {
+	switch len(args) {
+	case 0:
+	case 1:
+		def, ok := schema.Defs[args[0]]
+		if !ok {
+			return fmt.Errorf("unknown schema definition %q: expected one of get_params, put_params, source, version", args[0])
+		}
+		schema = &JSONSchema{
+			Schema:               schema.Schema,
+			Title:                args[0],
+			Description:          def.Description,
+			Type:                 def.Type,
+			Format:               def.Format,
+			Default:              def.Default,
+			Enum:                 def.Enum,
+			Properties:           def.Properties,
+			Required:             def.Required,
+			AdditionalProperties: def.AdditionalProperties,
+			Items:                def.Items,
+		}
+	default:
+		return fmt.Errorf("%s accepts at most one definition name argument", commandSchema)
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(schema)
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// typeSchema returns the schema for values of type t as encoding/json would decode them.
+// Types that are already being visited are described with an empty schema to terminate recursion.
+func typeSchema(t reflect.Type, customization Customization, visiting []reflect.Type) *JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, v := range visiting {
+		if v == t {
+			return &JSONSchema{}
+		}
+	}
+	visiting = append(visiting, t)
+
+	switch {
+	case t == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return &JSONSchema{}
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return &JSONSchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			return &JSONSchema{Type: "string", Format: "byte"}
+		}
+		return &JSONSchema{Type: "array", Items: typeSchema(t.Elem(), customization, visiting)}
+	case reflect.Map:
+		return &JSONSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), customization, visiting)}
+	case reflect.Struct:
+		s := &JSONSchema{Type: "object", Properties: make(map[string]*JSONSchema)}
+		if customization.DisallowUnknownFields {
+			s.AdditionalProperties = false
+		}
+		addStructProperties(s, t, customization, visiting)
+		return s
+	default:
+		return &JSONSchema{}
+	}
+}
+
+func addStructProperties(s *JSONSchema, t reflect.Type, customization Customization, visiting []reflect.Type) {
+	for _, field := range reflect.VisibleFields(t) {
+		if len(field.Index) > 1 {
+			continue // promoted fields are handled by the embedded struct case below
+		}
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addStructProperties(s, ft, customization, visiting)
+			}
+			continue
+		}
+		ps := typeSchema(field.Type, customization, visiting)
+		ps.Description = field.Tag.Get("description")
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			ps.Enum = enumValues(field.Type, enum)
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			ps.Default = defaultSchemaValue(field.Type, def)
+		}
+		if hasValidation(field, "required") {
+			s.Required = append(s.Required, name)
+		}
+		s.Properties[name] = ps
+	}
+}
+
+// jsonFieldName returns the name encoding/json uses for the field.
+// The name is empty for embedded structs without a json tag name; their fields are promoted.
+// ok is false when encoding/json ignores the field.
+func jsonFieldName(field reflect.StructField) (name string, ok bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, _, _ = strings.Cut(tag, ",")
+	if field.Anonymous && name == "" {
+		ft := field.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			return "", true
+		}
+	}
+	if !field.IsExported() {
+		return "", false
+	}
+	if name == "" {
+		name = field.Name
+	}
+	return name, true
+}
+
+// hasValidation reports whether the comma separated validate tag on field contains rule.
+func hasValidation(field reflect.StructField, rule string) bool {
+	for _, r := range strings.Split(field.Tag.Get("validate"), ",") {
+		if strings.TrimSpace(r) == rule {
+			return true
+		}
+	}
+	return false
+}
+
+func enumValues(t reflect.Type, tag string) []any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	var values []any
+	for _, s := range strings.Split(tag, ",") {
+		s = strings.TrimSpace(s)
+		var v any = s
+		switch t.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				v = n
+			}
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if n, err := strconv.ParseUint(s, 10, 64); err == nil {
+				v = n
+			}
+		case reflect.Float32, reflect.Float64:
+			if n, err := strconv.ParseFloat(s, 64); err == nil {
+				v = n
+			}
+		case reflect.Bool:
+			if b, err := strconv.ParseBool(s); err == nil {
+				v = b
+			}
+		}
+		values = append(values, v)
+	}
+	return values
+}
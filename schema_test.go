@@ -0,0 +1,119 @@
+package resource_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+type schemaTestSource struct {
+	URI     string            `json:"uri" validate:"required" description:"The git URI"`
+	Branch  string            `json:"branch,omitempty" enum:"main,develop"`
+	Depth   int               `json:"depth" enum:"1, 10"`
+	Labels  map[string]string `json:"labels"`
+	Since   *time.Time        `json:"since"`
+	Ignored string            `json:"-"`
+
+	schemaTestEmbedded
+}
+
+type schemaTestEmbedded struct {
+	Paths []string `json:"paths"`
+}
+
+func TestSchema(t *testing.T) {
+	schema := resource.Schema[schemaTestSource, example.GetParams, example.PutParams, example.Version](resource.Customization{DisallowUnknownFields: true})
+
+	buf, err := json.Marshal(schema.Defs["source"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp := `{"type":"object","properties":{` +
+		`"branch":{"type":"string","enum":["main","develop"]},` +
+		`"depth":{"type":"integer","enum":[1,10]},` +
+		`"labels":{"type":"object","additionalProperties":{"type":"string"}},` +
+		`"paths":{"type":"array","items":{"type":"string"}},` +
+		`"since":{"type":"string","format":"date-time"},` +
+		`"uri":{"description":"The git URI","type":"string"}` +
+		`},"required":["uri"],"additionalProperties":false}`
+	if string(buf) != exp {
+		t.Errorf("unexpected schema\nexp: %s\ngot: %s", exp, buf)
+	}
+
+	for _, name := range []string{"get_params", "put_params", "version"} {
+		if _, ok := schema.Defs[name]; !ok {
+			t.Errorf("expected definition %q", name)
+		}
+	}
+}
+
+func TestSchema_recursive(t *testing.T) {
+	type node struct {
+		Children []node `json:"children"`
+	}
+	schema := resource.Schema[node, node, node, node](resource.Customization{})
+
+	buf, err := json.Marshal(schema.Defs["source"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exp := `{"type":"object","properties":{"children":{"type":"array","items":{}}}}`; string(buf) != exp {
+		t.Errorf("unexpected schema\nexp: %s\ngot: %s", exp, buf)
+	}
+}
+
+func TestRun_schema(t *testing.T) {
+	mux := resource.RunWithCustomization(resource.Customization{}, new(fakeGet).Spy, new(fakePut).Spy, new(fakeCheck).Spy)
+
+	t.Run("document", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		if err := mux(stdout, new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "schema"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var schema resource.JSONSchema
+		if err := json.Unmarshal(stdout.Bytes(), &schema); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := schema.Defs["version"].Properties["ref"]; !ok {
+			t.Errorf("expected version definition to have ref property got %s", stdout)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		stdout := new(bytes.Buffer)
+		if err := mux(stdout, new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "schema", "get_params"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		exp := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "title": "get_params",
+  "type": "object",
+  "properties": {
+    "include_zip": {
+      "type": "boolean"
+    }
+  }
+}
+`
+		if stdout.String() != exp {
+			t.Errorf("unexpected schema\nexp: %s\ngot: %s", exp, stdout)
+		}
+	})
+
+	t.Run("unknown definition", func(t *testing.T) {
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(""), []string{"/bin/resource", "schema", "params"})
+
+		if exp := `unknown schema definition "params"`; err == nil || !strings.Contains(err.Error(), exp) {
+			t.Errorf("expected error containing %q got %v", exp, err)
+		}
+	})
+}
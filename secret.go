@@ -0,0 +1,161 @@
+package resource
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secret values in stderr output and errors.
+const Redacted = "[redacted]"
+
+// Secret is a string that is redacted when formatted with the fmt package.
+// Use a Secret (or a string field with the struct tag `sensitive:"true"`) in ResourceParams, GetParams, or PutParams
+// for tokens and keys. The function returned by Run also replaces the values, wherever they appear verbatim,
+// in everything written to stderr (including the logger passed to Get, Put, and Check) and in the error it returns.
+//
+// Use string(secret) to get the value.
+type Secret string
+
+func (Secret) String() string   { return Redacted }
+func (Secret) GoString() string { return fmt.Sprintf("%q", Redacted) }
+
+// Format implements fmt.Formatter so %s, %v, %q, and %x do not reveal the value.
+func (s Secret) Format(f fmt.State, verb rune) {
+	switch verb {
+	case 'q':
+		_, _ = fmt.Fprintf(f, "%q", Redacted)
+	case 'v':
+		if f.Flag('#') {
+			_, _ = io.WriteString(f, s.GoString())
+			return
+		}
+		_, _ = io.WriteString(f, Redacted)
+	default:
+		_, _ = io.WriteString(f, Redacted)
+	}
+}
+
+var secretType = reflect.TypeOf(Secret(""))
+
+// secretValues returns the non-empty values of Secret fields and string fields tagged sensitive:"true" in v.
+func secretValues(v any) []string {
+	var values []string
+	collectSecrets(&values, reflect.ValueOf(v), false)
+	return values
+}
+
+func collectSecrets(values *[]string, v reflect.Value, sensitive bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		if (sensitive || v.Type() == secretType) && v.Len() > 0 {
+			*values = append(*values, v.String())
+		}
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if _, ok := jsonFieldName(field); !ok {
+				continue
+			}
+			collectSecrets(values, v.Field(i), sensitive || field.Tag.Get("sensitive") == "true")
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectSecrets(values, v.Index(i), sensitive)
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			collectSecrets(values, iter.Value(), sensitive)
+		}
+	}
+}
+
+// redactor is an io.Writer that replaces secret values before writing to w.
+// Each Write is redacted independently, so a secret split across two writes is not redacted;
+// log.Logger and the slog handlers write each record with a single Write.
+type redactor struct {
+	mu      sync.Mutex
+	w       io.Writer
+	secrets []string
+}
+
+func newRedactor(w io.Writer) *redactor { return &redactor{w: w} }
+
+// add registers secret values and their JSON string encodings (so a private key with newlines is
+// also redacted from a JSON request dump). Longer values are replaced first so a secret containing
+// another is fully redacted.
+func (r *redactor) add(values ...string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, v := range values {
+		r.secrets = append(r.secrets, v)
+		if encoded, err := json.Marshal(v); err == nil {
+			if escaped := string(encoded[1 : len(encoded)-1]); escaped != v {
+				r.secrets = append(r.secrets, escaped)
+			}
+		}
+	}
+	sort.SliceStable(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+}
+
+// tee also writes the redacted output to w.
+func (r *redactor) tee(w io.Writer) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.w = io.MultiWriter(r.w, w)
+}
+
+func (r *redactor) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	redacted := p
+	for _, s := range r.secrets {
+		redacted = bytes.ReplaceAll(redacted, []byte(s), []byte(Redacted))
+	}
+	if _, err := r.w.Write(redacted); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (r *redactor) redact(s string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, secret := range r.secrets {
+		s = strings.ReplaceAll(s, secret, Redacted)
+	}
+	return s
+}
+
+// redactError returns err with secret values replaced in its message. errors.Is and errors.As still see err.
+func (r *redactor) redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	message := r.redact(err.Error())
+	if message == err.Error() {
+		return err
+	}
+	return &redactedError{message: message, err: err}
+}
+
+type redactedError struct {
+	message string
+	err     error
+}
+
+func (err *redactedError) Error() string { return err.message }
+func (err *redactedError) Unwrap() error { return err.err }
@@ -0,0 +1,134 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+type secretTestSource struct {
+	URI        string          `json:"uri"`
+	Token      resource.Secret `json:"token"`
+	PrivateKey string          `json:"private_key" sensitive:"true"`
+}
+
+type secretTestVersion struct {
+	Ref string `json:"ref"`
+}
+
+var errSecretTest = errors.New("banana")
+
+const secretStdin = `{"source": {"uri": "git://some-uri", "token": "t0k3n", "private_key": "-----BEGIN KEY-----"}, "version": {"ref": "abc"}}`
+
+func TestSecret(t *testing.T) {
+	secret := resource.Secret("t0k3n")
+
+	for _, format := range []string{"%s", "%v", "%+v", "%#v", "%q", "%x", "%d"} {
+		if got := fmt.Sprintf(format, secret); strings.Contains(got, "t0k3n") {
+			t.Errorf("expected %s to redact the secret got %q", format, got)
+		}
+	}
+	if got := fmt.Sprintf("%+v", secretTestSource{Token: secret}); strings.Contains(got, "t0k3n") {
+		t.Errorf("expected struct formatting to redact the secret got %q", got)
+	}
+	if string(secret) != "t0k3n" {
+		t.Errorf("expected conversion to string to return the value")
+	}
+}
+
+func TestRun_secrets(t *testing.T) {
+	t.Run("log.Logger", func(t *testing.T) {
+		mux := resource.RunWithCustomization(resource.Customization{DisallowUnknownFields: true},
+			func(context.Context, *log.Logger, secretTestSource, struct{}, secretTestVersion, string) ([]resource.MetadataField, error) {
+				return nil, nil
+			},
+			func(context.Context, *log.Logger, secretTestSource, struct{}, string) (secretTestVersion, []resource.MetadataField, error) {
+				return secretTestVersion{}, nil, nil
+			},
+			func(_ context.Context, logger *log.Logger, source secretTestSource, _ secretTestVersion) ([]secretTestVersion, error) {
+				logger.Printf("authenticating with %s and %s", string(source.Token), source.PrivateKey)
+				return nil, fmt.Errorf("token %s was rejected: %w", string(source.Token), errSecretTest)
+			},
+		)
+
+		stderr := new(bytes.Buffer)
+		err := mux(new(bytes.Buffer), stderr, strings.NewReader(secretStdin), []string{"check"})
+
+		if exp := "token [redacted] was rejected: banana"; err == nil || err.Error() != exp {
+			t.Errorf("expected error %q got %v", exp, err)
+		}
+		if !errors.Is(err, errSecretTest) {
+			t.Errorf("expected redacted error to wrap the original error")
+		}
+		if exp := "authenticating with [redacted] and [redacted]\n"; stderr.String() != exp {
+			t.Errorf("expected stderr %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("slog.Logger", func(t *testing.T) {
+		mux := resource.RunStructuredWithCustomization(resource.Customization{LogFormat: resource.LogFormatText},
+			func(context.Context, *slog.Logger, secretTestSource, struct{}, secretTestVersion, string) ([]resource.MetadataField, error) {
+				return nil, nil
+			},
+			func(context.Context, *slog.Logger, secretTestSource, struct{}, string) (secretTestVersion, []resource.MetadataField, error) {
+				return secretTestVersion{}, nil, nil
+			},
+			func(_ context.Context, logger *slog.Logger, source secretTestSource, _ secretTestVersion) ([]secretTestVersion, error) {
+				logger.Info("authenticating", "token", string(source.Token), "secret", source.Token)
+				return nil, nil
+			},
+		)
+
+		stderr := new(bytes.Buffer)
+		if err := mux(new(bytes.Buffer), stderr, strings.NewReader(secretStdin), []string{"check"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if exp := "INFO  authenticating token=[redacted] secret=[redacted]\n"; stderr.String() != exp {
+			t.Errorf("expected stderr %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("validation error", func(t *testing.T) {
+		mux := resource.RunWithCustomization(resource.Customization{},
+			func(context.Context, *log.Logger, secretTestSource, struct{}, secretTestVersion, string) ([]resource.MetadataField, error) {
+				return nil, nil
+			},
+			func(context.Context, *log.Logger, secretTestSource, struct{}, string) (secretTestVersion, []resource.MetadataField, error) {
+				return secretTestVersion{}, nil, nil
+			},
+			func(context.Context, *log.Logger, secretTestSource, secretTestVersion) ([]secretTestVersion, error) {
+				return nil, nil
+			},
+		)
+
+		stdin := `{"source": {"private_key": "-----BEGIN KEY-----"}}`
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"check"})
+
+		var validationErr *resource.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected a validation error got %v", err)
+		}
+		if exp := "source: uri is required to use key [redacted]"; !strings.Contains(err.Error(), exp) {
+			t.Errorf("expected error to contain %q got %q", exp, err)
+		}
+	})
+}
+
+func (source secretTestSource) Validate() error {
+	if source.URI == "" {
+		return fmt.Errorf("uri is required to use key %s", source.PrivateKey)
+	}
+	return nil
+}
+
+var secretTestCheck resource.Check[secretTestSource, secretTestVersion] = func(context.Context, *log.Logger, secretTestSource, secretTestVersion) ([]secretTestVersion, error) {
+	return nil, nil
+}
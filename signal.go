@@ -0,0 +1,55 @@
+package resource
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// exit is replaced in tests so the grace period can be observed without terminating the test binary.
+var exit = os.Exit
+
+// signalContext returns a context that is cancelled when the process receives SIGINT or SIGTERM.
+// A second signal, or the returned stop function not being called within a positive gracePeriod of the first,
+// makes the process exit with status 1.
+func signalContext(parent context.Context, stderr stderrLoggers, gracePeriod time.Duration) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-done:
+			return
+		case sig := <-signals:
+			stderr.warn.Printf("received %s: cancelling", sig)
+			cancel()
+		}
+		var timeout <-chan time.Time
+		if gracePeriod > 0 {
+			timer := time.NewTimer(gracePeriod)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-done:
+		case sig := <-signals:
+			stderr.error.Printf("received %s again: exiting", sig)
+			exit(1)
+		case <-timeout:
+			stderr.error.Printf("did not stop within shutdown grace period %s: exiting", gracePeriod)
+			exit(1)
+		}
+	}()
+	var once sync.Once
+	return ctx, func() {
+		once.Do(func() {
+			signal.Stop(signals)
+			close(done)
+			cancel()
+		})
+	}
+}
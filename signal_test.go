@@ -0,0 +1,117 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalContext(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sending SIGTERM is not supported on windows")
+	}
+
+	t.Run("cancel on signal", func(t *testing.T) {
+		stderr := new(bytes.Buffer)
+		ctx, stop := signalContext(context.Background(), newStderrLoggers(log.New(stderr, "", 0)), 0)
+		defer stop()
+
+		sendSIGTERM(t)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected context to be cancelled")
+		}
+		stop()
+		if exp := "received terminated"; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected log to contain %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("exit after grace period", func(t *testing.T) {
+		exited := make(chan int, 1)
+		exit = func(code int) { exited <- code }
+		t.Cleanup(func() { exit = os.Exit })
+
+		stderr := new(bytes.Buffer)
+		ctx, stop := signalContext(context.Background(), newStderrLoggers(log.New(stderr, "", 0)), time.Millisecond)
+		defer stop()
+
+		sendSIGTERM(t)
+		<-ctx.Done()
+
+		select {
+		case code := <-exited:
+			if code != 1 {
+				t.Errorf("expected exit code 1 got %d", code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected exit to be called")
+		}
+	})
+
+	t.Run("exit on second signal", func(t *testing.T) {
+		exited := make(chan int, 1)
+		exit = func(code int) { exited <- code }
+		t.Cleanup(func() { exit = os.Exit })
+
+		started := make(chan context.Context)
+		release := make(chan struct{})
+		check := func(ctx context.Context, _ *log.Logger, _ struct{}, _ struct{}) ([]struct{}, error) {
+			started <- ctx
+			<-release // ignores ctx
+			return nil, nil
+		}
+		stderr := new(bytes.Buffer)
+		mux := RunWithCustomization[struct{}, struct{}, struct{}, struct{}](Customization{}, nil, nil, check)
+		result := make(chan error, 1)
+		go func() {
+			result <- mux(new(bytes.Buffer), stderr, strings.NewReader(`{"source": {}}`), []string{"check"})
+		}()
+
+		ctx := <-started
+		sendSIGTERM(t)
+		<-ctx.Done()
+		sendSIGTERM(t)
+
+		select {
+		case code := <-exited:
+			if code != 1 {
+				t.Errorf("expected exit code 1 got %d", code)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected exit to be called")
+		}
+		close(release)
+		<-result
+		if exp := "received terminated again: exiting"; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected log to contain %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("stop before signal", func(t *testing.T) {
+		ctx, stop := signalContext(context.Background(), newStderrLoggers(log.New(new(bytes.Buffer), "", 0)), time.Millisecond)
+		stop()
+		if ctx.Err() == nil {
+			t.Errorf("expected stop to cancel the context")
+		}
+	})
+}
+
+func sendSIGTERM(t *testing.T) {
+	t.Helper()
+	p, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+}
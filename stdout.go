@@ -0,0 +1,109 @@
+package resource
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// stdoutDrainTimeout is how long restoring standard output waits for stray output to be copied.
+// A child process that outlives Get, Put, or Check can hold the pipe open, so the wait is bounded.
+const stdoutDrainTimeout = time.Second
+
+// callWithStdoutRedirected calls fn while anything written to the process standard output
+// (os.Stdout and, where supported, file descriptor 1 inherited by child processes) is copied to stderr instead,
+// a line at a time, so it is redacted and captured like everything else written there.
+// Concourse parses standard output as the response, so stray writes would corrupt it.
+func callWithStdoutRedirected[Res any](enabled bool, stderr io.Writer, fn func() (Res, error)) (Res, error) {
+	if !enabled {
+		return fn()
+	}
+	restore, err := redirectStdout(stderr)
+	if err != nil {
+		var zero Res
+		return zero, err
+	}
+	defer restore()
+	return fn()
+}
+
+// stdoutRedirect counts the calls sharing the redirect. os.Stdout and file descriptor 1 belong to the process,
+// so only the outermost of overlapping calls swaps them, and the last one to return restores them.
+// Stray output while calls overlap is written to the stderr of the call that started the redirect.
+var stdoutRedirect struct {
+	mu      sync.Mutex
+	calls   int
+	restore func()
+}
+
+func redirectStdout(stderr io.Writer) (func(), error) {
+	stdoutRedirect.mu.Lock()
+	defer stdoutRedirect.mu.Unlock()
+	if stdoutRedirect.calls == 0 {
+		restore, err := swapStdout(stderr)
+		if err != nil {
+			return nil, err
+		}
+		stdoutRedirect.restore = restore
+	}
+	stdoutRedirect.calls++
+	return func() {
+		stdoutRedirect.mu.Lock()
+		defer stdoutRedirect.mu.Unlock()
+		stdoutRedirect.calls--
+		if stdoutRedirect.calls == 0 {
+			stdoutRedirect.restore()
+			stdoutRedirect.restore = nil
+		}
+	}, nil
+}
+
+// swapStdout points os.Stdout and file descriptor 1 at a pipe copied to stderr.
+func swapStdout(stderr io.Writer) (func(), error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout := os.Stdout
+	restoreFD, err := redirectStdoutFD(stdout, w)
+	if err != nil {
+		_ = r.Close()
+		_ = w.Close()
+		return nil, err
+	}
+	os.Stdout = w
+	copied := make(chan struct{})
+	go func() {
+		defer close(copied)
+		copyLines(stderr, r)
+	}()
+	return func() {
+		os.Stdout = stdout
+		restoreFD()
+		_ = w.Close()
+		select {
+		case <-copied:
+		case <-time.After(stdoutDrainTimeout):
+		}
+		_ = r.Close()
+	}, nil
+}
+
+// copyLines writes each line read from r to w until r is closed. It keeps reading after a write fails
+// so writers to the pipe do not block.
+func copyLines(w io.Writer, r io.Reader) {
+	br := bufio.NewReader(r)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 && w != nil {
+			if _, writeErr := w.Write(line); writeErr != nil {
+				w = nil
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,6 @@
+package resource
+
+import "syscall"
+
+// dup2 uses Dup3 because syscall.Dup2 is not available on all linux architectures (for example arm64).
+func dup2(oldfd, newfd int) error { return syscall.Dup3(oldfd, newfd, 0) }
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package resource
+
+import "os"
+
+// redirectStdoutFD is a no-op on platforms without dup2; only os.Stdout is redirected.
+func redirectStdoutFD(_, _ *os.File) (func(), error) { return func() {}, nil }
@@ -0,0 +1,141 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+func TestRun_protectStdout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("child process output redirection is not supported on windows")
+	}
+
+	processStderr, err := os.CreateTemp(t.TempDir(), "stderr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	originalStderr := os.Stderr
+	os.Stderr = processStderr
+	t.Cleanup(func() {
+		os.Stderr = originalStderr
+		_ = processStderr.Close()
+	})
+
+	customization := resource.Customization{DisallowUnknownFields: true, ProtectStdout: true}
+
+	get := new(fakeGet)
+	put := new(fakePut)
+	check := new(fakeCheck)
+
+	check.Calls(func(context.Context, *log.Logger, example.Resource, example.Version) ([]example.Version, error) {
+		fmt.Println("stray fmt.Println")
+		cmd := exec.Command("sh", "-c", "echo stray child process")
+		cmd.Stdout = os.Stdout
+		if err := cmd.Run(); err != nil {
+			return nil, err
+		}
+		if _, err := syscall.Write(1, []byte("stray write to fd 1\n")); err != nil {
+			return nil, err
+		}
+		return []example.Version{{Ref: "abc"}}, nil
+	})
+
+	mux := resource.RunWithCustomization(customization, get.Spy, put.Spy, check.Spy)
+
+	stdout, stderr := new(bytes.Buffer), new(bytes.Buffer)
+	err = mux(stdout, stderr, strings.NewReader(checkStdin), []string{"check"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if os.Stdout == processStderr {
+		t.Errorf("expected os.Stdout to be restored")
+	}
+	if exp := `[{"ref":"abc"}]` + "\n"; stdout.String() != exp {
+		t.Errorf("expected stdout %q got %q", exp, stdout.String())
+	}
+	for _, exp := range []string{"stray fmt.Println\n", "stray child process\n", "stray write to fd 1\n"} {
+		if !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected stray output %q to be written to stderr got %q", exp, stderr.String())
+		}
+	}
+	if buf, err := os.ReadFile(processStderr.Name()); err != nil || len(buf) != 0 {
+		t.Errorf("expected nothing written to the process stderr got %q %v", buf, err)
+	}
+}
+
+func TestRun_protectStdoutOverlapping(t *testing.T) {
+	stdout := os.Stdout
+
+	// the first invocation returns while the second is still running
+	blockingCheck := func(started, release chan struct{}) *fakeCheck {
+		check := new(fakeCheck)
+		check.Calls(func(context.Context, *log.Logger, example.Resource, example.Version) ([]example.Version, error) {
+			close(started)
+			<-release
+			return nil, nil
+		})
+		return check
+	}
+	run := func(check *fakeCheck, done chan<- error) {
+		mux := resource.RunWithCustomization(resource.Customization{ProtectStdout: true}, new(fakeGet).Spy, new(fakePut).Spy, check.Spy)
+		done <- mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(checkStdin), []string{"check"})
+	}
+	firstStarted, firstRelease, firstDone := make(chan struct{}), make(chan struct{}), make(chan error)
+	secondStarted, secondRelease, secondDone := make(chan struct{}), make(chan struct{}), make(chan error)
+	go run(blockingCheck(firstStarted, firstRelease), firstDone)
+	<-firstStarted
+	go run(blockingCheck(secondStarted, secondRelease), secondDone)
+	<-secondStarted
+	close(firstRelease)
+	if err := <-firstDone; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	close(secondRelease)
+	if err := <-secondDone; err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if os.Stdout != stdout {
+		t.Errorf("expected os.Stdout to be restored")
+	}
+	if _, err := fmt.Fprint(os.Stdout, ""); err != nil {
+		t.Errorf("expected os.Stdout to be writable: %s", err)
+	}
+	if _, err := syscall.Write(1, nil); err != nil {
+		t.Errorf("expected file descriptor 1 to be writable: %s", err)
+	}
+}
+
+func TestRun_protectStdoutRedacted(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("child process output redirection is not supported on windows")
+	}
+
+	check := func(_ context.Context, _ *log.Logger, source secretTestSource, _ secretTestVersion) ([]secretTestVersion, error) {
+		cmd := exec.Command("sh", "-c", `echo "cloning with $1"`, "sh", string(source.Token))
+		cmd.Stdout = os.Stdout
+		return nil, cmd.Run()
+	}
+	mux := resource.RunWithCustomization[secretTestSource, struct{}, struct{}, secretTestVersion](resource.Customization{ProtectStdout: true}, nil, nil, check)
+
+	stderr := new(bytes.Buffer)
+	if err := mux(new(bytes.Buffer), stderr, strings.NewReader(secretStdin), []string{"check"}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if exp := "cloning with [redacted]\n"; stderr.String() != exp {
+		t.Errorf("expected stderr %q got %q", exp, stderr.String())
+	}
+}
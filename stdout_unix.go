@@ -0,0 +1,25 @@
+//go:build unix
+
+package resource
+
+import (
+	"os"
+	"syscall"
+)
+
+// redirectStdoutFD points the stdout file descriptor at to and returns a function that points it back.
+func redirectStdoutFD(stdout, to *os.File) (func(), error) {
+	stdoutFD, toFD := int(stdout.Fd()), int(to.Fd())
+	saved, err := syscall.Dup(stdoutFD)
+	if err != nil {
+		return nil, err
+	}
+	if err := dup2(toFD, stdoutFD); err != nil {
+		_ = syscall.Close(saved)
+		return nil, err
+	}
+	return func() {
+		_ = dup2(saved, stdoutFD)
+		_ = syscall.Close(saved)
+	}, nil
+}
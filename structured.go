@@ -0,0 +1,200 @@
+package resource
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+type (
+	StructuredGet[ResourceParams, GetParams, Version any] func(context.Context, *slog.Logger, ResourceParams, GetParams, Version, string) ([]MetadataField, error)
+	StructuredPut[ResourceParams, PutParams, Version any] func(context.Context, *slog.Logger, ResourceParams, PutParams, string) (Version, []MetadataField, error)
+	StructuredCheck[ResourceParams, Version any]          func(context.Context, *slog.Logger, ResourceParams, Version) ([]Version, error)
+)
+
+// LogFormat configures how the *slog.Logger passed to StructuredGet, StructuredPut, and StructuredCheck writes to stderr.
+type LogFormat int
+
+const (
+	// LogFormatColorText writes human-readable lines with the level highlighted using ANSI colors.
+	LogFormatColorText LogFormat = iota
+	// LogFormatText writes human-readable lines without colors.
+	LogFormatText
+	// LogFormatJSON writes one JSON object per line.
+	LogFormatJSON
+)
+
+// RunStructured is like Run but the functions receive a *slog.Logger that writes to stderr.
+// Debug records are written when Customization.LogLevel allows them or when the source has the field "debug": true.
+func RunStructured[ResourceParams, GetParams, PutParams, Version any](
+	in StructuredGet[ResourceParams, GetParams, Version],
+	out StructuredPut[ResourceParams, PutParams, Version],
+	check StructuredCheck[ResourceParams, Version],
+) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	return RunStructuredWithCustomization(Customization{ProtectStdout: true}, in, out, check)
+}
+
+// RunStructuredWithCustomization is like RunWithCustomization but the functions receive a *slog.Logger.
+// LoggerPrefix and LoggerFlags are ignored; LogLevel and LogFormat are used instead.
+// It panics with a *VersionError when Version can not encode as a JSON object with string values.
+func RunStructuredWithCustomization[ResourceParams, GetParams, PutParams, Version any](
+	customization Customization,
+	in StructuredGet[ResourceParams, GetParams, Version],
+	out StructuredPut[ResourceParams, PutParams, Version],
+	check StructuredCheck[ResourceParams, Version],
+) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	mustCheckVersionType[Version]()
+	return func(stdout io.Writer, stderr io.Writer, stdin io.Reader, args []string) error {
+		secrets := newRedactor(stderr)
+		newLogger := func(debug bool) *slog.Logger {
+			level := customization.LogLevel
+			if debug {
+				level = min(level, slog.LevelDebug)
+			}
+			return slog.New(newLogHandler(secrets, customization.LogFormat, level))
+		}
+		handler := newLogger(false).Handler()
+		loggers := stderrLoggers{
+			info:  slog.NewLogLogger(handler, slog.LevelInfo),
+			warn:  slog.NewLogLogger(handler, slog.LevelWarn),
+			error: slog.NewLogLogger(handler, slog.LevelError),
+		}
+		traceLogger := slog.NewLogLogger(newLogger(true).Handler(), slog.LevelDebug)
+		return dispatch(customization, stdout, loggers, traceLogger, secrets, stdin, args, newLogger, in.run, out.run, check.run)
+	}
+}
+
+func (in StructuredGet[ResourceParams, GetParams, Version]) run(ctx context.Context, log *slog.Logger, req inRequest[ResourceParams, GetParams, Version], args []string) (inResponse[Version], error) {
+	m, err := in(ctx, log, req.Source, req.Params, req.Version, args[0])
+	return inResponse[Version]{Version: req.Version, VersionMetadata: m}, err
+}
+
+func (out StructuredPut[ResourceParams, PutParams, Version]) run(ctx context.Context, log *slog.Logger, req outRequest[ResourceParams, PutParams, Version], args []string) (outResponse[Version], error) {
+	v, m, err := out(ctx, log, req.Source, req.Params, args[0])
+	return outResponse[Version]{Version: v, VersionMetadata: m}, err
+}
+
+func (fn StructuredCheck[ResourceParams, Version]) run(ctx context.Context, log *slog.Logger, req checkRequest[ResourceParams, Version], _ []string) (checkResponse[Version], error) {
+	return fn(ctx, log, req.Source, req.Version)
+}
+
+func newLogHandler(w io.Writer, format LogFormat, level slog.Level) slog.Handler {
+	switch format {
+	case LogFormatJSON:
+		return slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})
+	default:
+		return &textHandler{
+			mu:    new(sync.Mutex),
+			w:     w,
+			level: level,
+			color: format == LogFormatColorText,
+		}
+	}
+}
+
+// textHandler writes records as "LEVEL message key=value ..." lines.
+// Unlike slog.TextHandler it omits the time (Concourse already shows when output was written)
+// and can highlight the level.
+type textHandler struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	level  slog.Level
+	color  bool
+	prefix string
+	attrs  string
+}
+
+func (h *textHandler) Enabled(_ context.Context, level slog.Level) bool { return level >= h.level }
+
+func (h *textHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(h.levelString(r.Level))
+	b.WriteByte(' ')
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+	b.WriteByte('\n')
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *textHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *textHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+func (h *textHandler) levelString(level slog.Level) string {
+	s := fmt.Sprintf("%-5s", level.String())
+	if !h.color {
+		return s
+	}
+	var code int
+	switch {
+	case level >= slog.LevelError:
+		code = 31 // red
+	case level >= slog.LevelWarn:
+		code = 33 // yellow
+	case level >= slog.LevelInfo:
+		code = 32 // green
+	default:
+		code = 90 // gray
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", code, s)
+}
+
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+	b.WriteByte(' ')
+	b.WriteString(prefix)
+	b.WriteString(a.Key)
+	b.WriteByte('=')
+	b.WriteString(quoteIfNeeded(a.Value.String()))
+}
+
+func quoteIfNeeded(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
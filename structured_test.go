@@ -0,0 +1,143 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+func runStructured(customization resource.Customization, check resource.StructuredCheck[example.Resource, example.Version]) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	get := func(context.Context, *slog.Logger, example.Resource, example.GetParams, example.Version, string) ([]resource.MetadataField, error) {
+		return nil, nil
+	}
+	put := func(context.Context, *slog.Logger, example.Resource, example.PutParams, string) (example.Version, []resource.MetadataField, error) {
+		return example.Version{}, nil, nil
+	}
+	return resource.RunStructuredWithCustomization[example.Resource, example.GetParams, example.PutParams, example.Version](customization, get, put, check)
+}
+
+func TestRunStructured(t *testing.T) {
+	logCheck := func(ctx context.Context, logger *slog.Logger, source example.Resource, version example.Version) ([]example.Version, error) {
+		logger.Debug("looking for versions", "uri", source.URI)
+		logger.With("branch", source.Branch).WithGroup("version").Info("found version", "ref", version.Ref, "note", "two words")
+		return []example.Version{version}, nil
+	}
+
+	t.Run("text", func(t *testing.T) {
+		customization := resource.Customization{LogFormat: resource.LogFormatText}
+		mux := runStructured(customization, logCheck)
+
+		stdout := new(bytes.Buffer)
+		stderr := new(bytes.Buffer)
+		err := mux(stdout, stderr, strings.NewReader(checkStdin), []string{"/some/absolute-path/check"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if exp := "INFO  found version branch=develop version.ref=pear version.note=\"two words\"\n"; stderr.String() != exp {
+			t.Errorf("expected stderr %q got %q", exp, stderr.String())
+		}
+		if exp := `[{"ref":"pear"}]` + "\n"; stdout.String() != exp {
+			t.Errorf("expected stdout %q got %q", exp, stdout.String())
+		}
+	})
+
+	t.Run("debug from source", func(t *testing.T) {
+		customization := resource.Customization{LogFormat: resource.LogFormatText}
+		mux := runStructured(customization, logCheck)
+
+		stdin := `{"source": {"uri": "git://some-uri", "debug": true}, "version": {"ref": "pear"}}`
+		stderr := new(bytes.Buffer)
+		err := mux(new(bytes.Buffer), stderr, strings.NewReader(stdin), []string{"check"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if exp := "DEBUG looking for versions uri=git://some-uri\n"; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected stderr to contain %q got %q", exp, stderr.String())
+		}
+		if exp := "DEBUG debug: response: [{\"ref\":\"pear\"}]\n"; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected stderr to contain %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("level", func(t *testing.T) {
+		customization := resource.Customization{LogFormat: resource.LogFormatText, LogLevel: slog.LevelWarn}
+		mux := runStructured(customization, logCheck)
+
+		stderr := new(bytes.Buffer)
+		err := mux(new(bytes.Buffer), stderr, strings.NewReader(checkStdin), []string{"check"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if stderr.Len() != 0 {
+			t.Errorf("expected no output got %q", stderr.String())
+		}
+	})
+
+	t.Run("color", func(t *testing.T) {
+		get := func(context.Context, *slog.Logger, example.Resource, example.GetParams, example.Version, string) ([]resource.MetadataField, error) {
+			return nil, nil
+		}
+		put := func(context.Context, *slog.Logger, example.Resource, example.PutParams, string) (example.Version, []resource.MetadataField, error) {
+			return example.Version{}, nil, nil
+		}
+		mux := resource.RunStructured[example.Resource, example.GetParams, example.PutParams, example.Version](get, put, logCheck)
+
+		stderr := new(bytes.Buffer)
+		err := mux(new(bytes.Buffer), stderr, strings.NewReader(checkStdin), []string{"check"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if exp := "\x1b[32mINFO \x1b[0m found version"; !strings.HasPrefix(stderr.String(), exp) {
+			t.Errorf("expected stderr to start with %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		customization := resource.Customization{LogFormat: resource.LogFormatJSON}
+		mux := runStructured(customization, logCheck)
+
+		stderr := new(bytes.Buffer)
+		err := mux(new(bytes.Buffer), stderr, strings.NewReader(checkStdin), []string{"check"})
+		if err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if exp := `"msg":"found version","branch":"develop","version":{"ref":"pear","note":"two words"}}`; !strings.Contains(stderr.String(), exp) {
+			t.Errorf("expected stderr to contain %q got %q", exp, stderr.String())
+		}
+	})
+
+	t.Run("dispatcher levels", func(t *testing.T) {
+		customization := resource.Customization{LogFormat: resource.LogFormatText}
+		mux := runStructured(customization, func(context.Context, *slog.Logger, example.Resource, example.Version) ([]example.Version, error) {
+			panic("banana")
+		})
+
+		stderr := new(bytes.Buffer)
+		if err := mux(new(bytes.Buffer), stderr, strings.NewReader(checkStdin), []string{"check"}); err == nil {
+			t.Fatal("expected an error")
+		}
+		if exp := "ERROR check panicked: banana\n"; !strings.HasPrefix(stderr.String(), exp) {
+			t.Errorf("expected stderr to start with %q got %q", exp, stderr.String())
+		}
+
+		stderr.Reset()
+		if err := mux(new(bytes.Buffer), stderr, strings.NewReader(""), []string{"/bin/resource", "install", "-copy", t.TempDir()}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if exp := "INFO  installed "; !strings.HasPrefix(stderr.String(), exp) {
+			t.Errorf("expected stderr to start with %q got %q", exp, stderr.String())
+		}
+	})
+
+}
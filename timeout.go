@@ -0,0 +1,35 @@
+package resource
+
+import (
+	"fmt"
+	"time"
+)
+
+// TimeoutError is returned by the function produced by Run when the context passed
+// to Get, Put, or Check reaches the deadline configured in Customization.
+type TimeoutError struct {
+	Command string
+	Timeout time.Duration
+
+	// Err is the error returned by Get, Put, or Check.
+	Err error
+}
+
+func (err *TimeoutError) Error() string {
+	return fmt.Sprintf("%s timed out after %s", err.Command, err.Timeout)
+}
+
+func (err *TimeoutError) Unwrap() error { return err.Err }
+
+func (c Customization) timeout(command string) time.Duration {
+	switch command {
+	case commandIn:
+		return c.GetTimeout
+	case commandOut:
+		return c.PutTimeout
+	case commandCheck:
+		return c.CheckTimeout
+	default:
+		return 0
+	}
+}
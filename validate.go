@@ -0,0 +1,138 @@
+package resource
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Validator may be implemented by the ResourceParams, GetParams, PutParams, and Version types
+// (or any struct they contain). Validate is called after the request is decoded and before Get, Put, or Check.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError is returned by the function produced by Run when the decoded request is not valid.
+// It lists every problem found, not just the first.
+type ValidationError struct {
+	Problems []error
+}
+
+func (err *ValidationError) Error() string {
+	var b strings.Builder
+	b.WriteString("invalid request:")
+	for _, p := range err.Problems {
+		b.WriteString("\n  - ")
+		b.WriteString(strings.ReplaceAll(p.Error(), "\n", "\n    "))
+	}
+	return b.String()
+}
+
+func (err *ValidationError) Unwrap() []error { return err.Problems }
+
+// validateRequest checks the validate and enum struct tags on the request and calls Validate on any Validator.
+// The supported validate rules are
+//
+//	required   the field must not be the zero value
+//	omitempty  the field (and anything it contains) is not validated when it is the zero value
+func validateRequest(req any) error {
+	var problems []error
+	validateValue(&problems, reflect.ValueOf(req).Elem(), "")
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func validateValue(problems *[]error, v reflect.Value, path string) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, ok := jsonFieldName(field)
+			if !ok {
+				continue
+			}
+			fieldPath := path
+			if name != "" {
+				fieldPath = joinPath(path, name)
+			}
+			fv := v.Field(i)
+			if fv.IsZero() {
+				if hasValidation(field, "required") {
+					*problems = append(*problems, fmt.Errorf("%s is required", fieldPath))
+				}
+				if hasValidation(field, "omitempty") {
+					continue
+				}
+			} else if enum, ok := field.Tag.Lookup("enum"); ok {
+				validateEnum(problems, fv, fieldPath, enum)
+			}
+			validateValue(problems, fv, fieldPath)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			validateValue(problems, v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			validateValue(problems, v.MapIndex(key), joinPath(path, fmt.Sprint(key)))
+		}
+	}
+	validateMethod(problems, v, path)
+}
+
+func validateMethod(problems *[]error, v reflect.Value, path string) {
+	if !v.CanInterface() {
+		return
+	}
+	validator, _ := v.Interface().(Validator)
+	if v.CanAddr() {
+		validator, _ = v.Addr().Interface().(Validator)
+	}
+	if validator == nil {
+		return
+	}
+	if err := validator.Validate(); err != nil {
+		if path != "" {
+			err = fmt.Errorf("%s: %w", path, err)
+		}
+		*problems = append(*problems, err)
+	}
+}
+
+func validateEnum(problems *[]error, v reflect.Value, path, enum string) {
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if !v.CanInterface() {
+		return
+	}
+	value := fmt.Sprint(v.Interface())
+	var options []string
+	for _, option := range strings.Split(enum, ",") {
+		option = strings.TrimSpace(option)
+		if option == value {
+			return
+		}
+		options = append(options, option)
+	}
+	*problems = append(*problems, fmt.Errorf("%s must be one of %s but got %q", path, strings.Join(options, ", "), value))
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
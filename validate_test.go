@@ -0,0 +1,111 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/crhntr/resource"
+)
+
+type validateTestSource struct {
+	URI    string `json:"uri" validate:"required"`
+	Branch string `json:"branch" enum:"main,develop"`
+	Auth   struct {
+		Token string `json:"token" validate:"required"`
+	} `json:"auth" validate:"omitempty"`
+	Mirrors []validateTestMirror `json:"mirrors"`
+}
+
+type validateTestMirror struct {
+	URI string `json:"uri" validate:"required"`
+}
+
+type validateTestParams struct {
+	Depth int `json:"depth"`
+}
+
+func (p validateTestParams) Validate() error {
+	if p.Depth < 0 {
+		return errors.New("depth must not be negative")
+	}
+	return nil
+}
+
+type validateTestVersion struct {
+	Ref string `json:"ref" validate:"required"`
+}
+
+func validateTestRun(called *bool) func(stdout, stderr io.Writer, stdin io.Reader, args []string) error {
+	return resource.RunWithCustomization(resource.Customization{},
+		func(context.Context, *log.Logger, validateTestSource, validateTestParams, validateTestVersion, string) ([]resource.MetadataField, error) {
+			*called = true
+			return nil, nil
+		},
+		func(context.Context, *log.Logger, validateTestSource, validateTestParams, string) (validateTestVersion, []resource.MetadataField, error) {
+			*called = true
+			return validateTestVersion{Ref: "abc"}, nil, nil
+		},
+		func(context.Context, *log.Logger, validateTestSource, validateTestVersion) ([]validateTestVersion, error) {
+			*called = true
+			return nil, nil
+		},
+	)
+}
+
+func TestRun_validation(t *testing.T) {
+	t.Run("problems are aggregated", func(t *testing.T) {
+		var called bool
+		mux := validateTestRun(&called)
+
+		stdin := `{"source": {"branch": "feature", "auth": {"token": ""}, "mirrors": [{"uri": "a"}, {}]}, "params": {"depth": -1}, "version": {"ref": ""}}`
+		err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"in", "some-dir"})
+
+		var validationErr *resource.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected a validation error got %v", err)
+		}
+		exp := `invalid request:
+  - source.uri is required
+  - source.branch must be one of main, develop but got "feature"
+  - source.mirrors[1].uri is required
+  - params: depth must not be negative
+  - version.ref is required`
+		if err.Error() != exp {
+			t.Errorf("unexpected error\nexp: %s\ngot: %s", exp, err)
+		}
+		if called {
+			t.Errorf("expected the function not to be called")
+		}
+	})
+
+	t.Run("omitempty", func(t *testing.T) {
+		var called bool
+		mux := validateTestRun(&called)
+
+		stdin := `{"source": {"uri": "git://some-uri", "auth": {"token": "secret"}}}`
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"out", "some-dir"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !called {
+			t.Errorf("expected the function to be called")
+		}
+	})
+
+	t.Run("first check has no version", func(t *testing.T) {
+		var called bool
+		mux := validateTestRun(&called)
+
+		stdin := `{"source": {"uri": "git://some-uri"}, "version": null}`
+		if err := mux(new(bytes.Buffer), new(bytes.Buffer), strings.NewReader(stdin), []string{"check"}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+		if !called {
+			t.Errorf("expected the function to be called")
+		}
+	})
+}
@@ -0,0 +1,348 @@
+package resource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VersionComparer may be implemented by a Version type (with a value or pointer receiver) to order versions chronologically.
+// Compare returns a negative number when the receiver is older than other, zero when they are
+// the same age, and a positive number when it is newer.
+type VersionComparer[Version any] interface {
+	Compare(other Version) int
+}
+
+// VersionOrder post-processes the versions returned by Check so they follow the Concourse protocol:
+// in chronological order (oldest first), without duplicates, and starting with the requested version.
+type VersionOrder[Version any] struct {
+	// Compare orders versions like VersionComparer.Compare. When it is nil, the Compare method of Version
+	// (or *Version) is used, and when neither implements VersionComparer, the versions are assumed to already be in order.
+	Compare func(a, b Version) int
+
+	// Max is the maximum number of versions to return. The newest versions are kept.
+	// The zero value does not limit the number of versions.
+	Max int
+}
+
+// Apply sorts versions, removes duplicates (versions with the same JSON encoding), drops versions older than
+// requested, and keeps at most Max of the newest. A zero requested version (the first check) drops nothing.
+// The versions slice is not modified.
+func (order VersionOrder[Version]) Apply(versions []Version, requested Version) []Version {
+	compare := order.compareFunc()
+	result := make([]Version, 0, len(versions))
+	seen := make(map[string]bool, len(versions))
+	for _, v := range versions {
+		key, err := json.Marshal(v)
+		if err == nil {
+			if seen[string(key)] {
+				continue
+			}
+			seen[string(key)] = true
+		}
+		result = append(result, v)
+	}
+	if compare != nil {
+		sort.SliceStable(result, func(i, j int) bool { return compare(result[i], result[j]) < 0 })
+	}
+
+	if !reflect.ValueOf(&requested).Elem().IsZero() {
+		start := -1
+		requestedKey, _ := json.Marshal(requested)
+		for i, v := range result {
+			if key, _ := json.Marshal(v); string(key) == string(requestedKey) {
+				start = i
+				break
+			}
+		}
+		if start < 0 && compare != nil {
+			start = sort.Search(len(result), func(i int) bool { return compare(result[i], requested) >= 0 })
+		}
+		if start > 0 {
+			result = result[start:]
+		}
+	}
+
+	if order.Max > 0 && len(result) > order.Max {
+		result = result[len(result)-order.Max:]
+	}
+	return result
+}
+
+func (order VersionOrder[Version]) compareFunc() func(a, b Version) int {
+	if order.Compare != nil {
+		return order.Compare
+	}
+	var zero Version
+	if _, ok := any(zero).(VersionComparer[Version]); ok {
+		return func(a, b Version) int { return any(a).(VersionComparer[Version]).Compare(b) }
+	}
+	if _, ok := any(&zero).(VersionComparer[Version]); ok {
+		return func(a, b Version) int { return any(&a).(VersionComparer[Version]).Compare(b) }
+	}
+	return nil
+}
+
+// Ordered returns a Check that applies order to the versions returned by fn.
+//
+//	check := resource.Check[Source, Version](check).Ordered(resource.VersionOrder[Version]{Max: 100})
+func (fn Check[ResourceParams, Version]) Ordered(order VersionOrder[Version]) Check[ResourceParams, Version] {
+	return func(ctx context.Context, logger *log.Logger, source ResourceParams, version Version) ([]Version, error) {
+		versions, err := fn(ctx, logger, source, version)
+		if err != nil {
+			return versions, err
+		}
+		return order.Apply(versions, version), nil
+	}
+}
+
+// Ordered returns a StructuredCheck that applies order to the versions returned by fn.
+func (fn StructuredCheck[ResourceParams, Version]) Ordered(order VersionOrder[Version]) StructuredCheck[ResourceParams, Version] {
+	return func(ctx context.Context, logger *slog.Logger, source ResourceParams, version Version) ([]Version, error) {
+		versions, err := fn(ctx, logger, source, version)
+		if err != nil {
+			return versions, err
+		}
+		return order.Apply(versions, version), nil
+	}
+}
+
+// VersionError describes a Version type or value that does not encode as a JSON object with string values,
+// which Concourse requires. RunWithCustomization and RunStructuredWithCustomization panic with a *VersionError
+// when the Version type can not encode that way. The function they return also checks each version it writes
+// and returns a *VersionError instead of writing the response.
+type VersionError struct {
+	// Type is the Version type.
+	Type reflect.Type
+
+	// Field is the JSON name of the offending field. It is empty when the version is not a JSON object.
+	Field string
+
+	// Kind is what the version or field encodes as, for example "number" or "object".
+	// It is "null" for a pointer field without the json "omitempty" option, which encodes as null when it is nil.
+	Kind string
+}
+
+func (err *VersionError) Error() string {
+	if err.Field == "" {
+		return fmt.Sprintf("version type %s encodes as a JSON %s; Concourse requires an object with string values", err.Type, err.Kind)
+	}
+	if err.Kind == "null" {
+		return fmt.Sprintf("version type %s field %q encodes as a JSON null when it is nil; Concourse requires string values "+
+			`(use the json "omitempty" option so nil is left out)`, err.Type, err.Field)
+	}
+	return fmt.Sprintf("version type %s field %q encodes as a JSON %s; Concourse requires string values "+
+		`(use a string, the json ",string" option, VersionInt, VersionBool, or VersionTime)`, err.Type, err.Field, err.Kind)
+}
+
+// mustCheckVersionType panics when Version can not encode as a JSON object with string values.
+func mustCheckVersionType[Version any]() {
+	if err := checkVersionType(reflect.TypeOf((*Version)(nil)).Elem()); err != nil {
+		panic(err)
+	}
+}
+
+// checkVersionType returns a *VersionError when values of type t do not encode as a JSON object with string values.
+// Types that implement json.Marshaler and interface types are only checked when encoded.
+func checkVersionType(t reflect.Type) error {
+	root := t
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case implements(t, jsonMarshalerType):
+		return nil
+	case implements(t, textMarshalerType):
+		return &VersionError{Type: root, Kind: "string"}
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return nil
+	case reflect.Map:
+		if kind := jsonKind(t.Elem()); kind != "string" {
+			return &VersionError{Type: root, Kind: "object with " + kind + " values"}
+		}
+		return nil
+	case reflect.Struct:
+		return checkVersionFields(root, t)
+	default:
+		return &VersionError{Type: root, Kind: jsonKind(t)}
+	}
+}
+
+func checkVersionFields(root, t reflect.Type) error {
+	for _, field := range reflect.VisibleFields(t) {
+		if len(field.Index) > 1 {
+			continue // promoted fields are checked with the embedded struct below
+		}
+		name, ok := jsonFieldName(field)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if err := checkVersionFields(root, ft); err != nil {
+				return err
+			}
+			continue
+		}
+		if kind := jsonKind(field.Type); kind != "string" && !(kind != "object" && kind != "array" && hasJSONOption(field, "string")) {
+			return &VersionError{Type: root, Field: name, Kind: kind}
+		}
+		if field.Type.Kind() == reflect.Pointer && !hasJSONOption(field, "omitempty") {
+			return &VersionError{Type: root, Field: name, Kind: "null"}
+		}
+	}
+	return nil
+}
+
+// jsonKind returns the kind of JSON value encoding/json writes for values of type t.
+// Types that encode themselves and interfaces are reported as "string"; they are checked when encoded.
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if implements(t, jsonMarshalerType) || implements(t, textMarshalerType) {
+		return "string"
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Interface:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return "string"
+		}
+		return "array"
+	case reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return t.Kind().String()
+	}
+}
+
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+// hasJSONOption reports whether the json tag on field has the option, for example "string" in `json:"n,string"`.
+func hasJSONOption(field reflect.StructField, option string) bool {
+	_, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+	return hasOption(options, option)
+}
+
+// checkVersionValue returns a *VersionError when v does not encode as a JSON object with string values.
+func checkVersionValue[Version any](v Version) error {
+	buf, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	t := reflect.TypeOf((*Version)(nil)).Elem()
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(buf, &fields); err != nil || fields == nil {
+		return &VersionError{Type: t, Kind: rawKind(buf)}
+	}
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if kind := rawKind(fields[key]); kind != "string" {
+			return &VersionError{Type: t, Field: key, Kind: kind}
+		}
+	}
+	return nil
+}
+
+func rawKind(raw []byte) string {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 {
+		return "null"
+	}
+	switch raw[0] {
+	case '"':
+		return "string"
+	case '{':
+		return "object"
+	case '[':
+		return "array"
+	case 't', 'f':
+		return "boolean"
+	case 'n':
+		return "null"
+	default:
+		return "number"
+	}
+}
+
+// VersionInt is an int64 that encodes as a JSON string so it can be used as a Version field.
+type VersionInt int64
+
+func (v VersionInt) MarshalText() ([]byte, error) { return strconv.AppendInt(nil, int64(v), 10), nil }
+
+func (v *VersionInt) UnmarshalText(text []byte) error {
+	n, err := strconv.ParseInt(string(text), 10, 64)
+	*v = VersionInt(n)
+	return err
+}
+
+// VersionBool is a bool that encodes as the JSON string "true" or "false" so it can be used as a Version field.
+type VersionBool bool
+
+func (v VersionBool) MarshalText() ([]byte, error) { return strconv.AppendBool(nil, bool(v)), nil }
+
+func (v *VersionBool) UnmarshalText(text []byte) error {
+	b, err := strconv.ParseBool(string(text))
+	*v = VersionBool(b)
+	return err
+}
+
+// VersionTime is a time.Time that encodes as an RFC 3339 string in UTC so it can be used as a Version field.
+// Unlike time.Time, the encoding does not depend on the local time zone, so the same instant is always the same version.
+type VersionTime struct {
+	time.Time
+}
+
+func (v VersionTime) MarshalText() ([]byte, error) {
+	return v.Time.UTC().AppendFormat(nil, time.RFC3339Nano), nil
+}
+
+func (v *VersionTime) UnmarshalText(text []byte) error {
+	t, err := time.Parse(time.RFC3339Nano, string(text))
+	v.Time = t
+	return err
+}
+
+// MarshalJSON and UnmarshalJSON replace the methods promoted from time.Time.
+
+func (v VersionTime) MarshalJSON() ([]byte, error) {
+	text, _ := v.MarshalText()
+	return json.Marshal(string(text))
+}
+
+func (v *VersionTime) UnmarshalJSON(buf []byte) error {
+	var s string
+	if err := json.Unmarshal(buf, &s); err != nil {
+		return err
+	}
+	return v.UnmarshalText([]byte(s))
+}
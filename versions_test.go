@@ -0,0 +1,242 @@
+package resource_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/crhntr/resource"
+	"github.com/crhntr/resource/internal/example"
+)
+
+type buildNumber struct {
+	Number string `json:"number"`
+}
+
+func (v buildNumber) Compare(other buildNumber) int {
+	a, _ := strconv.Atoi(v.Number)
+	b, _ := strconv.Atoi(other.Number)
+	return a - b
+}
+
+func buildNumbers(numbers ...int) []buildNumber {
+	versions := make([]buildNumber, 0, len(numbers))
+	for _, n := range numbers {
+		versions = append(versions, buildNumber{Number: strconv.Itoa(n)})
+	}
+	return versions
+}
+
+func TestVersionOrder_Apply(t *testing.T) {
+	for _, tt := range []struct {
+		name      string
+		order     resource.VersionOrder[buildNumber]
+		versions  []buildNumber
+		requested buildNumber
+		expected  []buildNumber
+	}{
+		{name: "sort and dedupe", versions: buildNumbers(3, 1, 2, 3, 1), expected: buildNumbers(1, 2, 3)},
+		{name: "drop older than requested", versions: buildNumbers(4, 1, 2, 3), requested: buildNumber{Number: "2"}, expected: buildNumbers(2, 3, 4)},
+		{name: "requested version removed", versions: buildNumbers(1, 3, 4), requested: buildNumber{Number: "2"}, expected: buildNumbers(3, 4)},
+		{name: "max keeps newest", order: resource.VersionOrder[buildNumber]{Max: 2}, versions: buildNumbers(1, 2, 3), expected: buildNumbers(2, 3)},
+		{name: "empty", versions: nil, requested: buildNumber{Number: "2"}, expected: buildNumbers()},
+		{
+			name:     "compare func",
+			order:    resource.VersionOrder[buildNumber]{Compare: func(a, b buildNumber) int { return b.Compare(a) }},
+			versions: buildNumbers(1, 3, 2),
+			expected: buildNumbers(3, 2, 1),
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.order.Apply(tt.versions, tt.requested)
+			if fmt.Sprint(got) != fmt.Sprint(tt.expected) {
+				t.Errorf("expected %v got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+type pointerBuildNumber struct {
+	Number string `json:"number"`
+}
+
+func (v *pointerBuildNumber) Compare(other pointerBuildNumber) int {
+	return buildNumber(*v).Compare(buildNumber(other))
+}
+
+func TestVersionOrder_Apply_pointerReceiver(t *testing.T) {
+	versions := []pointerBuildNumber{{Number: "4"}, {Number: "1"}, {Number: "3"}, {Number: "2"}}
+
+	got := resource.VersionOrder[pointerBuildNumber]{}.Apply(versions, pointerBuildNumber{Number: "2"})
+
+	if exp := "[{2} {3} {4}]"; fmt.Sprint(got) != exp {
+		t.Errorf("expected %v got %v", exp, got)
+	}
+}
+
+func TestVersionOrder_Apply_withoutCompare(t *testing.T) {
+	versions := []example.Version{{Ref: "a"}, {Ref: "b"}, {Ref: "a"}, {Ref: "c"}, {Ref: "d"}}
+
+	got := resource.VersionOrder[example.Version]{Max: 2}.Apply(versions, example.Version{Ref: "b"})
+
+	if exp := []example.Version{{Ref: "c"}, {Ref: "d"}}; fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Errorf("expected %v got %v", exp, got)
+	}
+	if exp := "[{a} {b} {a} {c} {d}]"; fmt.Sprint(versions) != exp {
+		t.Errorf("expected versions not to be modified got %v", versions)
+	}
+}
+
+func TestCheck_Ordered(t *testing.T) {
+	check := resource.Check[example.Resource, buildNumber](func(context.Context, *log.Logger, example.Resource, buildNumber) ([]buildNumber, error) {
+		return buildNumbers(5, 1, 3, 4, 3), nil
+	}).Ordered(resource.VersionOrder[buildNumber]{Max: 2})
+
+	mux := resource.RunWithCustomization[example.Resource, struct{}, struct{}, buildNumber](resource.Customization{}, nil, nil, check)
+
+	stdout := new(bytes.Buffer)
+	if err := mux(stdout, new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"number": "3"}}`), []string{"check"}); err != nil {
+		t.Fatal(err)
+	}
+	if exp := `[{"number":"4"},{"number":"5"}]` + "\n"; stdout.String() != exp {
+		t.Errorf("expected %q got %q", exp, stdout.String())
+	}
+}
+
+func TestRunWithCustomization_versionType(t *testing.T) {
+	type embedded struct {
+		Count int `json:"count"`
+	}
+	for _, tt := range []struct {
+		name     string
+		run      func()
+		expected string
+	}{
+		{
+			name: "int field",
+			run: func() {
+				type version struct {
+					Ref   string `json:"ref"`
+					Count int    `json:"count"`
+				}
+				resource.RunWithCustomization[struct{}, struct{}, struct{}, version](resource.Customization{}, nil, nil, nil)
+			},
+			expected: `version type resource_test.version field "count" encodes as a JSON number; Concourse requires string values (use a string, the json ",string" option, VersionInt, VersionBool, or VersionTime)`,
+		},
+		{
+			name: "embedded struct field",
+			run: func() {
+				type version struct {
+					embedded
+				}
+				resource.RunStructuredWithCustomization[struct{}, struct{}, struct{}, version](resource.Customization{}, nil, nil, nil)
+			},
+			expected: `version type resource_test.version field "count" encodes as a JSON number; Concourse requires string values (use a string, the json ",string" option, VersionInt, VersionBool, or VersionTime)`,
+		},
+		{
+			name: "nested object",
+			run: func() {
+				type version struct {
+					Labels map[string]string `json:"labels"`
+				}
+				resource.RunWithCustomization[struct{}, struct{}, struct{}, version](resource.Customization{}, nil, nil, nil)
+			},
+			expected: `version type resource_test.version field "labels" encodes as a JSON object; Concourse requires string values (use a string, the json ",string" option, VersionInt, VersionBool, or VersionTime)`,
+		},
+		{
+			name: "pointer field without omitempty",
+			run: func() {
+				type version struct {
+					Ref *string `json:"ref"`
+				}
+				resource.RunWithCustomization[struct{}, struct{}, struct{}, version](resource.Customization{}, nil, nil, nil)
+			},
+			expected: `version type resource_test.version field "ref" encodes as a JSON null when it is nil; Concourse requires string values (use the json "omitempty" option so nil is left out)`,
+		},
+		{
+			name: "map with int values",
+			run: func() {
+				resource.RunWithCustomization[struct{}, struct{}, struct{}, map[string]int](resource.Customization{}, nil, nil, nil)
+			},
+			expected: `version type map[string]int encodes as a JSON object with number values; Concourse requires an object with string values`,
+		},
+		{
+			name: "string",
+			run: func() {
+				resource.RunWithCustomization[struct{}, struct{}, struct{}, string](resource.Customization{}, nil, nil, nil)
+			},
+			expected: `version type string encodes as a JSON string; Concourse requires an object with string values`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				r := recover()
+				err, ok := r.(*resource.VersionError)
+				if !ok {
+					t.Fatalf("expected a *resource.VersionError panic got %#v", r)
+				}
+				if err.Error() != tt.expected {
+					t.Errorf("expected %q got %q", tt.expected, err.Error())
+				}
+			}()
+			tt.run()
+		})
+	}
+}
+
+func TestRunWithCustomization_versionTypeAllowed(t *testing.T) {
+	type version struct {
+		Ref     string               `json:"ref"`
+		Count   int                  `json:"count,string"`
+		Build   resource.VersionInt  `json:"build"`
+		Dirty   resource.VersionBool `json:"dirty"`
+		Created resource.VersionTime `json:"created"`
+		Pointer *string              `json:"pointer,omitempty"`
+		Ignored []string             `json:"-"`
+	}
+	check := func(context.Context, *log.Logger, struct{}, version) ([]version, error) {
+		return []version{{Ref: "a", Count: 2, Build: 42, Dirty: true, Created: resource.VersionTime{Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.FixedZone("EST", -5*60*60))}}}, nil
+	}
+	mux := resource.RunWithCustomization[struct{}, struct{}, struct{}, version](resource.Customization{}, nil, nil, check)
+
+	stdout := new(bytes.Buffer)
+	if err := mux(stdout, new(bytes.Buffer), strings.NewReader(`{"source": {}, "version": {"ref": "a", "count": "1", "build": "41", "dirty": "false", "created": "2024-01-02T08:04:05Z"}}`), []string{"check"}); err != nil {
+		t.Fatal(err)
+	}
+	if exp := `[{"ref":"a","count":"2","build":"42","dirty":"true","created":"2024-01-02T08:04:05Z"}]` + "\n"; stdout.String() != exp {
+		t.Errorf("expected %q got %q", exp, stdout.String())
+	}
+}
+
+type numberVersion struct {
+	Ref string
+}
+
+func (v numberVersion) MarshalJSON() ([]byte, error) { return []byte(`{"ref": 1}`), nil }
+
+func TestRun_versionValue(t *testing.T) {
+	put := func(context.Context, *log.Logger, struct{}, struct{}, string) (numberVersion, []resource.MetadataField, error) {
+		return numberVersion{}, nil, nil
+	}
+	mux := resource.RunWithCustomization[struct{}, struct{}, struct{}, numberVersion](resource.Customization{}, nil, put, nil)
+
+	stdout := new(bytes.Buffer)
+	err := mux(stdout, new(bytes.Buffer), strings.NewReader(`{"source": {}}`), []string{"out", t.TempDir()})
+
+	var versionErr *resource.VersionError
+	if !errors.As(err, &versionErr) {
+		t.Fatalf("expected a *resource.VersionError got %v", err)
+	}
+	if exp := `version type resource_test.numberVersion field "ref" encodes as a JSON number; Concourse requires string values (use a string, the json ",string" option, VersionInt, VersionBool, or VersionTime)`; err.Error() != exp {
+		t.Errorf("expected %q got %q", exp, err.Error())
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no response got %q", stdout.String())
+	}
+}